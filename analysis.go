@@ -0,0 +1,197 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/geo/r3"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+)
+
+const (
+	defaultAnalyzeLines  = 3
+	defaultAnalyzeMillis = 1000
+	hintPointTime        = 3 * time.Second // how long the gripper stays over the hint
+)
+
+type AnalyzeCmd struct {
+	N      int  // lines, defaults to 3
+	Millis int  // engine time, defaults to 1000
+	Point  bool // point the gripper at the piece to move
+}
+
+// analysisLine is one line the engine found. Scores are for the side to move.
+type analysisLine struct {
+	pv   []*chess.Move
+	cp   int
+	mate int // moves to mate, negative when getting mated, 0 if no mate
+}
+
+func (l analysisLine) toMap() map[string]interface{} {
+	pv := []string{}
+	for _, m := range l.pv {
+		pv = append(pv, m.String())
+	}
+	m := map[string]interface{}{
+		"move":     pv[0],
+		"pv":       pv,
+		"score_cp": l.cp,
+	}
+	if l.mate != 0 {
+		m["mate"] = l.mate
+	}
+	return m
+}
+
+// fallbackLine turns a built in engine score into a line, mates are scored mateScore less the plies to get there.
+func fallbackLine(sm scoredMove) analysisLine {
+	l := analysisLine{pv: []*chess.Move{sm.move}, cp: sm.score}
+	switch {
+	case sm.score > mateScore-1000:
+		l.mate = (mateScore - sm.score + 1) / 2
+	case sm.score < -mateScore+1000:
+		l.mate = -(mateScore + sm.score + 1) / 2
+	}
+	return l
+}
+
+// engineLines pulls the lines out of a MultiPV search, best first.
+func engineLines(results uci.SearchResults) []analysisLine {
+	lines := []analysisLine{}
+	for _, info := range results.MultiPVInfo {
+		if len(info.PV) == 0 {
+			continue
+		}
+		lines = append(lines, analysisLine{pv: info.PV, cp: info.Score.CP, mate: info.Score.Mate})
+	}
+	if len(lines) == 0 && results.BestMove != nil {
+		lines = append(lines, analysisLine{pv: []*chess.Move{results.BestMove}})
+	}
+	return lines
+}
+
+// analyze finds the best n lines at full strength, whatever skill the player is set to.
+func (p *enginePlayer) analyze(ctx context.Context, pos *chess.Position, n int, moveTime time.Duration) ([]analysisLine, error) {
+	if p.engine == nil && n == 1 { // a lot quicker than scoring every move
+		m, score, err := p.fallback.bestMove(ctx, pos, p.conf.fallbackDepth())
+		if err != nil {
+			return nil, err
+		}
+		return []analysisLine{fallbackLine(scoredMove{m, score})}, nil
+	}
+	if p.engine == nil {
+		scored, err := p.fallback.topMoves(ctx, pos, p.conf.fallbackDepth(), n)
+		if err != nil {
+			return nil, err
+		}
+		lines := []analysisLine{}
+		for _, sm := range scored {
+			lines = append(lines, fallbackLine(sm))
+		}
+		return lines, nil
+	}
+
+	full := newEngineStrength(100, 0, 0)
+	full.multiPV = n
+	_, err := full.apply(p.engine)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := p.setupEngine(p.engine)
+		if err != nil {
+			p.logger.Warnf("can't put engine strength back: %v", err)
+		}
+	}()
+
+	results, err := p.engine.search(ctx, uci.CmdPosition{Position: pos}, uci.CmdGo{MoveTime: moveTime})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := engineLines(results)
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return lines, nil
+}
+
+func (s *viamChessChess) analyze(ctx context.Context, cmd AnalyzeCmd) (map[string]interface{}, error) {
+	n := cmd.N
+	if n <= 0 {
+		n = defaultAnalyzeLines
+	}
+	millis := cmd.Millis
+	if millis <= 0 {
+		millis = defaultAnalyzeMillis
+	}
+
+	theState, err := s.getGame(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if over, result := s.isOver(theState.game); over {
+		return nil, fmt.Errorf("game is over: %s", result)
+	}
+
+	pos := theState.game.Position()
+	lines, err := s.player.analyze(ctx, pos, n, time.Duration(millis)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("engine found nothing")
+	}
+
+	res := []interface{}{}
+	for _, l := range lines {
+		res = append(res, l.toMap())
+	}
+
+	best := lines[0].pv[0]
+	if cmd.Point {
+		err = s.pointAt(ctx, best.S1().String(), theState)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"turn":  pos.Turn().Name(),
+		"best":  best.String(),
+		"lines": res,
+	}, nil
+}
+
+// pointAt holds the gripper over a square for a bit, without touching anything.
+func (s *viamChessChess) pointAt(ctx context.Context, square string, theState *state) error {
+	all, err := s.capture(ctx)
+	if err != nil {
+		return err
+	}
+
+	center, err := s.getCenterFor(all, square, theState)
+	if err != nil {
+		return err
+	}
+
+	ws, err := s.worldState(all, theState)
+	if err != nil {
+		return err
+	}
+
+	err = s.moveGripper(ctx, r3.Vector{X: center.X, Y: center.Y, Z: s.safeZ()}, ws)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(hintPointTime):
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package viamchess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestAnalyzeFallback(t *testing.T) {
+	p, err := newEnginePlayer(&PlayerConfig{Engine: builtinEngine, FallbackDepth: 2}, logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer p.Close()
+
+	lines, err := p.analyze(context.Background(), positionFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"), 3, time.Second)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, lines, test.ShouldHaveLength, 3)
+
+	best := lines[0].toMap()
+	test.That(t, best["move"], test.ShouldEqual, "a1a8")
+	test.That(t, best["mate"], test.ShouldEqual, 1)
+	test.That(t, lines[1].cp, test.ShouldBeLessThan, lines[0].cp)
+	test.That(t, lines[2].cp, test.ShouldBeLessThanOrEqualTo, lines[1].cp)
+}
+
+func TestAnalyzeEngine(t *testing.T) {
+	path := fakeEngine(t, `echo "info depth 10 multipv 1 score cp 35 pv e2e4 e7e5"; `+
+		`echo "info depth 10 multipv 2 score cp 20 pv d2d4 d7d5"; `+
+		`echo "info depth 10 multipv 3 score mate -2 pv f2f3 e7e5"; echo "bestmove e2e4"`)
+	skill := 10.0
+	p, err := newEnginePlayer(&PlayerConfig{Engine: path, Skill: &skill}, logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer p.Close()
+
+	lines, err := p.analyze(context.Background(), chess.StartingPosition(), 2, 10*time.Millisecond)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, lines, test.ShouldHaveLength, 2)
+	test.That(t, lines[0].toMap()["pv"], test.ShouldResemble, []string{"e2e4", "e7e5"})
+	test.That(t, lines[0].cp, test.ShouldEqual, 35)
+	test.That(t, lines[1].pv[0].String(), test.ShouldEqual, "d2d4")
+
+	lines, err = p.analyze(context.Background(), chess.StartingPosition(), 5, 10*time.Millisecond)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, lines, test.ShouldHaveLength, 3)
+	test.That(t, lines[2].toMap()["mate"], test.ShouldEqual, -2)
+
+	// back to playing at its own skill
+	test.That(t, p.strength.skill, test.ShouldEqual, 10.0)
+	test.That(t, p.strength.toMap(p.strengthOptions)["options"].(map[string]interface{})["MultiPV"], test.ShouldEqual, "4")
+}
@@ -7,6 +7,7 @@ import (
 	"image"
 	"image/color"
 	"image/jpeg"
+	"sync"
 
 	"github.com/golang/geo/r3"
 
@@ -77,6 +78,9 @@ type BoardFinderCam struct {
 	logger     logging.Logger
 	source     camera.Camera
 	outputSize int
+
+	mu    sync.Mutex
+	board spatialmath.Geometry // last board found by NextPointCloud or Geometries
 }
 
 func (c *BoardFinderCam) Name() resource.Name {
@@ -87,8 +91,86 @@ func (c *BoardFinderCam) DoCommand(ctx context.Context, cmd map[string]interface
 	return nil, fmt.Errorf("DoCommand not supported")
 }
 
+// Geometries returns the board as a box in the source camera's frame, fit to its point cloud. The planner asks
+// for it a lot, so it's the last board NextPointCloud found, and only looked for here if there isn't one yet.
 func (c *BoardFinderCam) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
-	return nil, nil
+	c.mu.Lock()
+	board := c.board
+	c.mu.Unlock()
+	if board != nil {
+		return []spatialmath.Geometry{board}, nil
+	}
+
+	pc, err := c.source.NextPointCloud(ctx, extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pointcloud from source: %w", err)
+	}
+
+	imgs, _, err := c.source.Images(ctx, nil, extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get images from source: %w", err)
+	}
+
+	if len(imgs) == 0 {
+		return nil, fmt.Errorf("no images from source camera")
+	}
+
+	srcImg, err := imgs[0].Image(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	props, err := c.source.Properties(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get camera properties: %w", err)
+	}
+
+	g, err := boardGeometry(srcImg, pc, props)
+	if err != nil {
+		return nil, err
+	}
+	c.setBoard(g)
+	return []spatialmath.Geometry{g}, nil
+}
+
+func (c *BoardFinderCam) setBoard(g spatialmath.Geometry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.board = g
+}
+
+// boardGeometry finds the board in the image, fits its plane, and returns it as a box in camera coordinates.
+func boardGeometry(img image.Image, pc pointcloud.PointCloud, props camera.Properties) (spatialmath.Geometry, error) {
+	corners, err := findBoard(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find board: %w", err)
+	}
+	return boardGeometryAt(corners, pc, props)
+}
+
+// boardGeometryAt is boardGeometry with the corners already found.
+func boardGeometryAt(corners []image.Point, pc pointcloud.PointCloud, props camera.Properties) (spatialmath.Geometry, error) {
+	grid, err := newBoardGrid(corners)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := boardFrameFromPlane(pc, props, grid)
+	if err != nil {
+		return nil, err
+	}
+
+	pose, err := frame.a1Pose()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &boardPose{pose: pose, squareSize: frame.squareSize()}
+	g, err := b.geometry()
+	if err != nil {
+		return nil, err
+	}
+	return g.Transform(pose), nil
 }
 
 func (c *BoardFinderCam) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
@@ -178,6 +260,14 @@ func (c *BoardFinderCam) NextPointCloud(ctx context.Context, extra map[string]in
 		return nil, fmt.Errorf("failed to filter pointcloud: %w", err)
 	}
 
+	// keep the board for Geometries while we have everything it needs
+	g, err := boardGeometryAt(corners, pc, props)
+	if err != nil {
+		c.logger.Debugf("can't fit the board: %v", err)
+	} else {
+		c.setBoard(g)
+	}
+
 	return filtered, nil
 }
 
@@ -1,10 +1,14 @@
 package viamchess
 
 import (
+	"context"
 	"image"
 	"testing"
 
+	"github.com/golang/geo/r3"
+
 	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/spatialmath"
 	"go.viam.com/test"
 )
 
@@ -150,3 +154,15 @@ func TestPerspectiveTransformBasic(t *testing.T) {
 	test.That(t, output.Bounds().Dx(), test.ShouldEqual, outputSize)
 	test.That(t, output.Bounds().Dy(), test.ShouldEqual, outputSize)
 }
+
+func TestBoardFinderCamGeometriesCached(t *testing.T) {
+	board, err := spatialmath.NewBox(spatialmath.NewZeroPose(), r3.Vector{X: 400, Y: 400, Z: 10}, "board")
+	test.That(t, err, test.ShouldBeNil)
+
+	// no source camera, so it can only answer from what it has
+	c := &BoardFinderCam{}
+	c.setBoard(board)
+	gs, err := c.Geometries(context.Background(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, gs, test.ShouldResemble, []spatialmath.Geometry{board})
+}
@@ -0,0 +1,154 @@
+package viamchess
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// boardFrame is the board surface in camera coordinates.
+// A point is origin + u*uAxis + v*vAxis + h*normal, where u and v are board coordinates
+// (see boardGrid) and h is the height above the board in mm.
+type boardFrame struct {
+	origin r3.Vector
+	uAxis  r3.Vector // one square along u
+	vAxis  r3.Vector // one square along v
+	normal r3.Vector // unit, pointing at the camera
+
+	inv [9]float64
+}
+
+// newBoardFrame builds a frame from the 3d positions of the board corners at board
+// coordinates (0,0), (8,0), (8,8), (0,8).
+func newBoardFrame(corners []r3.Vector) (*boardFrame, error) {
+	if len(corners) != 4 {
+		return nil, fmt.Errorf("need 4 board corners, got %d", len(corners))
+	}
+
+	f := &boardFrame{
+		origin: corners[0],
+		uAxis:  corners[1].Sub(corners[0]).Add(corners[2].Sub(corners[3])).Mul(1.0 / 16),
+		vAxis:  corners[3].Sub(corners[0]).Add(corners[2].Sub(corners[1])).Mul(1.0 / 16),
+	}
+
+	f.normal = f.uAxis.Cross(f.vAxis)
+	if f.normal.Norm() < 1e-9 {
+		return nil, fmt.Errorf("board corners are degenerate: %v", corners)
+	}
+	f.normal = f.normal.Normalize()
+	if f.normal.Dot(f.origin) > 0 { // the camera is at the origin
+		f.normal = f.normal.Mul(-1)
+	}
+
+	var ok bool
+	f.inv, ok = invert3([9]float64{
+		f.uAxis.X, f.vAxis.X, f.normal.X,
+		f.uAxis.Y, f.vAxis.Y, f.normal.Y,
+		f.uAxis.Z, f.vAxis.Z, f.normal.Z,
+	})
+	if !ok {
+		return nil, fmt.Errorf("board corners are degenerate: %v", corners)
+	}
+
+	return f, nil
+}
+
+func (f *boardFrame) toBoard(p r3.Vector) (u, v, h float64) {
+	d := p.Sub(f.origin)
+	m := f.inv
+	return m[0]*d.X + m[1]*d.Y + m[2]*d.Z,
+		m[3]*d.X + m[4]*d.Y + m[5]*d.Z,
+		m[6]*d.X + m[7]*d.Y + m[8]*d.Z
+}
+
+func (f *boardFrame) fromBoard(u, v, h float64) r3.Vector {
+	return f.origin.Add(f.uAxis.Mul(u)).Add(f.vAxis.Mul(v)).Add(f.normal.Mul(h))
+}
+
+// squareSize is the measured length of a square's side in mm.
+func (f *boardFrame) squareSize() float64 {
+	return (f.uAxis.Norm() + f.vAxis.Norm()) / 2
+}
+
+// squareOptions controls which points belong to a square.
+type squareOptions struct {
+	inset     float64 // fraction of a square ignored on every side, so pieces and printing next door don't bleed in
+	maxHeight float64 // mm above the board, anything higher isn't a piece
+}
+
+// squareFor names the square under board coordinates u,v, or "" if they are off the board or within inset of an edge.
+func squareFor(u, v, inset float64) string {
+	if u < 0 || u >= 8 || v < 0 || v >= 8 {
+		return ""
+	}
+	fu, fv := u-math.Floor(u), v-math.Floor(v)
+	if fu < inset || fu > 1-inset || fv < inset || fv > 1-inset {
+		return ""
+	}
+	return fmt.Sprintf("%c%d", 'h'-rune(u), int(v)+1)
+}
+
+// splitBoardCloud buckets the point cloud by square. With a frame, points are placed by where they
+// really are above the board; without one, by where they land in the image.
+func splitBoardCloud(pc pointcloud.PointCloud, props camera.Properties, grid *boardGrid, frame *boardFrame, opts squareOptions) (map[string]pointcloud.PointCloud, error) {
+	if props.IntrinsicParams == nil {
+		return nil, fmt.Errorf("camera does not have intrinsic parameters")
+	}
+
+	const belowBoard = 10.0 // mm of depth noise allowed under the board surface
+
+	squares := map[string]pointcloud.PointCloud{}
+	var err error
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		var u, v float64
+		if frame != nil {
+			var h float64
+			u, v, h = frame.toBoard(p)
+			if h < -belowBoard || h > opts.maxHeight {
+				return true
+			}
+		} else {
+			x, y := props.IntrinsicParams.PointToPixel(p.X, p.Y, p.Z)
+			u, v = grid.boardPoint(x, y)
+		}
+
+		name := squareFor(u, v, opts.inset)
+		if name == "" {
+			return true
+		}
+
+		sq, ok := squares[name]
+		if !ok {
+			sq = pointcloud.NewBasicEmpty()
+			squares[name] = sq
+		}
+		err = sq.Set(p, d)
+		return err == nil
+	})
+
+	return squares, err
+}
+
+func invert3(m [9]float64) ([9]float64, bool) {
+	det := m[0]*(m[4]*m[8]-m[5]*m[7]) -
+		m[1]*(m[3]*m[8]-m[5]*m[6]) +
+		m[2]*(m[3]*m[7]-m[4]*m[6])
+	if math.Abs(det) < 1e-12 {
+		return [9]float64{}, false
+	}
+	return [9]float64{
+		(m[4]*m[8] - m[5]*m[7]) / det,
+		(m[2]*m[7] - m[1]*m[8]) / det,
+		(m[1]*m[5] - m[2]*m[4]) / det,
+		(m[5]*m[6] - m[3]*m[8]) / det,
+		(m[0]*m[8] - m[2]*m[6]) / det,
+		(m[2]*m[3] - m[0]*m[5]) / det,
+		(m[3]*m[7] - m[4]*m[6]) / det,
+		(m[1]*m[6] - m[0]*m[7]) / det,
+		(m[0]*m[4] - m[1]*m[3]) / det,
+	}, true
+}
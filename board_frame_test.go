@@ -0,0 +1,60 @@
+package viamchess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/vmodutils/touch"
+)
+
+func TestBoardFrameRoundTrip(t *testing.T) {
+	props := touch.RealSenseProperties
+	g, err := newBoardGrid([]image.Point{{300, 80}, {1010, 120}, {980, 700}, {260, 660}})
+	test.That(t, err, test.ShouldBeNil)
+
+	f := syntheticBoardFrame(t, props, g, 600)
+	test.That(t, f.normal.Z, test.ShouldBeLessThan, 0) // towards the camera
+
+	for _, uvh := range [][3]float64{{0, 0, 0}, {8, 8, 0}, {3.5, 2.25, 40}, {7, 1, -5}} {
+		u, v, h := f.toBoard(f.fromBoard(uvh[0], uvh[1], uvh[2]))
+		test.That(t, u, test.ShouldAlmostEqual, uvh[0], 1e-6)
+		test.That(t, v, test.ShouldAlmostEqual, uvh[1], 1e-6)
+		test.That(t, h, test.ShouldAlmostEqual, uvh[2], 1e-6)
+	}
+}
+
+func TestSquareFor(t *testing.T) {
+	test.That(t, squareFor(0.5, 0.5, .1), test.ShouldEqual, "h1")
+	test.That(t, squareFor(7.5, 7.5, .1), test.ShouldEqual, "a8")
+	test.That(t, squareFor(3.5, 1.5, .1), test.ShouldEqual, "e2")
+	test.That(t, squareFor(3.05, 1.5, .1), test.ShouldEqual, "")
+	test.That(t, squareFor(3.05, 1.5, 0), test.ShouldEqual, "e2")
+	test.That(t, squareFor(-.1, 1.5, 0), test.ShouldEqual, "")
+	test.That(t, squareFor(8, 1.5, 0), test.ShouldEqual, "")
+}
+
+func TestTallPieceStaysOnItsSquare(t *testing.T) {
+	props := touch.RealSenseProperties
+	g, err := newBoardGrid([]image.Point{{250, 20}, {1030, 20}, {1030, 700}, {250, 700}})
+	test.That(t, err, test.ShouldBeNil)
+
+	// a tall piece near the corner of the image leans over its neighbours in the image
+	pc := syntheticBoardCloud(t, props, syntheticBoardFrame(t, props, g, 600), map[string]syntheticPiece{
+		"b2": {color.NRGBA{240, 240, 240, 255}, 120},
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, props.IntrinsicParams.Width, props.IntrinsicParams.Height))
+	_, squares, err := boardDebugImage(img, pc, props, g, estimateBoardFrame(pc, props, g), squareOptions{inset: .1, maxHeight: 150})
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, s := range squares {
+		if s.name == "b2" {
+			test.That(t, s.color, test.ShouldEqual, 1)
+		} else {
+			test.That(t, s.color, test.ShouldEqual, 0)
+		}
+	}
+}
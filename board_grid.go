@@ -0,0 +1,120 @@
+package viamchess
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// boardGrid maps between image pixels and board coordinates with a homography.
+// Board coordinates run 0-8 in both directions: u goes across the image with the h file
+// at u=0, v goes down the image with rank 1 at v=0.
+type boardGrid struct {
+	corners []point // top-left, top-right, bottom-right, bottom-left in image pixels
+	toImage [9]float64
+	toBoard [9]float64
+}
+
+var boardGridCorners = []point{{0, 0}, {8, 0}, {8, 8}, {0, 8}}
+
+// newBoardGrid builds a grid from the four board corners in the order findBoard returns them:
+// top-left, top-right, bottom-right, bottom-left.
+func newBoardGrid(corners []image.Point) (*boardGrid, error) {
+	if len(corners) != 4 {
+		return nil, fmt.Errorf("need 4 board corners, got %d", len(corners))
+	}
+
+	pts := make([]point, 4)
+	for i, c := range corners {
+		pts[i] = point{float64(c.X), float64(c.Y)}
+	}
+
+	if polygonArea(pts) < 64 {
+		return nil, fmt.Errorf("board corners are degenerate: %v", corners)
+	}
+
+	return &boardGrid{
+		corners: pts,
+		toImage: computePerspectiveMatrix(boardGridCorners, pts),
+		toBoard: computePerspectiveMatrix(pts, boardGridCorners),
+	}, nil
+}
+
+// defaultBoardGrid assumes the board is the largest square centered in the image.
+func defaultBoardGrid(bounds image.Rectangle) *boardGrid {
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	g, err := newBoardGrid([]image.Point{
+		{x0, y0},
+		{x0 + side, y0},
+		{x0 + side, y0 + side},
+		{x0, y0 + side},
+	})
+	if err != nil {
+		// only possible for a tiny image, fall back to an identity-ish grid
+		return &boardGrid{
+			corners: boardGridCorners,
+			toImage: [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+			toBoard: [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		}
+	}
+	return g
+}
+
+// squareCell returns the board coordinates of the top-left of a square's cell.
+func squareCell(file rune, rank int) (float64, float64) {
+	return float64('h' - file), float64(rank - 1)
+}
+
+func (g *boardGrid) imagePoint(u, v float64) point {
+	x, y := applyPerspective(g.toImage, u, v)
+	return point{x, y}
+}
+
+func (g *boardGrid) boardPoint(x, y float64) (float64, float64) {
+	return applyPerspective(g.toBoard, x, y)
+}
+
+// squareQuad returns the image polygon for a square, shrunk by inset (a fraction of a square) on every side.
+func (g *boardGrid) squareQuad(file rune, rank int, inset float64) []point {
+	u, v := squareCell(file, rank)
+	return []point{
+		g.imagePoint(u+inset, v+inset),
+		g.imagePoint(u+1-inset, v+inset),
+		g.imagePoint(u+1-inset, v+1-inset),
+		g.imagePoint(u+inset, v+1-inset),
+	}
+}
+
+// sideLength is the average length of the board's edges in pixels.
+func (g *boardGrid) sideLength() float64 {
+	total := 0.0
+	for i := range g.corners {
+		a, b := g.corners[i], g.corners[(i+1)%len(g.corners)]
+		total += math.Hypot(b.x-a.x, b.y-a.y)
+	}
+	return total / float64(len(g.corners))
+}
+
+func quadBounds(quad []point) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range quad {
+		minX = math.Min(minX, p.x)
+		minY = math.Min(minY, p.y)
+		maxX = math.Max(maxX, p.x)
+		maxY = math.Max(maxY, p.y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+func polygonArea(poly []point) float64 {
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.x*b.y - b.x*a.y
+	}
+	return math.Abs(area) / 2
+}
@@ -0,0 +1,154 @@
+package viamchess
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/test"
+
+	"github.com/erh/vmodutils/touch"
+)
+
+type syntheticPiece struct {
+	color  color.NRGBA
+	height float64
+}
+
+// syntheticBoardFrame is the frame of a board lying flat depth away from the camera.
+func syntheticBoardFrame(t *testing.T, props camera.Properties, grid *boardGrid, depth float64) *boardFrame {
+	corners := []r3.Vector{}
+	for _, c := range grid.corners {
+		x, y, z := props.IntrinsicParams.PixelToPoint(c.x, c.y, depth)
+		corners = append(corners, r3.Vector{X: x, Y: y, Z: z})
+	}
+	f, err := newBoardFrame(corners)
+	test.That(t, err, test.ShouldBeNil)
+	return f
+}
+
+// syntheticTiltedBoard is a board with 45mm squares centered depth away on the camera's axis,
+// tilted by tilt degrees about the camera's x axis.
+func syntheticTiltedBoard(t *testing.T, props camera.Properties, depth, tilt float64) (*boardGrid, *boardFrame) {
+	s, c := math.Sin(tilt*math.Pi/180), math.Cos(tilt*math.Pi/180)
+	corners := []r3.Vector{}
+	imgCorners := []image.Point{}
+	for _, bc := range boardGridCorners {
+		x, y := (bc.x-4)*45, (bc.y-4)*45
+		p := r3.Vector{X: x, Y: y * c, Z: depth + y*s}
+		corners = append(corners, p)
+		px, py := props.IntrinsicParams.PointToPixel(p.X, p.Y, p.Z)
+		imgCorners = append(imgCorners, image.Point{int(math.Round(px)), int(math.Round(py))})
+	}
+
+	f, err := newBoardFrame(corners)
+	test.That(t, err, test.ShouldBeNil)
+	g, err := newBoardGrid(imgCorners)
+	test.That(t, err, test.ShouldBeNil)
+	return g, f
+}
+
+// syntheticBoardCloud makes a table in the plane of frame with the given pieces standing on their
+// squares. Piece tops are placed in 3d, so tall pieces away from the middle of the image land over
+// neighbouring squares in the image, like they do for a real camera.
+func syntheticBoardCloud(t *testing.T, props camera.Properties, frame *boardFrame, pieces map[string]syntheticPiece) pointcloud.PointCloud {
+	pc := pointcloud.NewBasicEmpty()
+	intr := props.IntrinsicParams
+	table := orientedPlane(frame.normal, frame.origin)
+	for y := 0; y < intr.Height; y += 3 {
+		for x := 0; x < intr.Width; x += 3 {
+			dx, dy, dz := intr.PixelToPoint(float64(x), float64(y), 1)
+			p, ok := table.intersectRay(r3.Vector{X: dx, Y: dy, Z: dz})
+			if !ok {
+				continue
+			}
+			test.That(t, pc.Set(p, pointcloud.NewColoredData(color.NRGBA{60, 120, 60, 255})), test.ShouldBeNil)
+		}
+	}
+
+	for name, piece := range pieces {
+		u, v := squareCell(rune(name[0]), int(name[1]-'0'))
+		for du := -.3; du <= .3; du += .03 {
+			for dv := -.3; dv <= .3; dv += .03 {
+				if du*du+dv*dv > .09 {
+					continue
+				}
+				p := frame.fromBoard(u+.5+du, v+.5+dv, piece.height)
+				test.That(t, pc.Set(p, pointcloud.NewColoredData(piece.color)), test.ShouldBeNil)
+			}
+		}
+	}
+	return pc
+}
+
+func TestDefaultBoardGridLandscapeOffset(t *testing.T) {
+	g := defaultBoardGrid(image.Rect(100, 50, 740, 530))
+
+	h1 := quadBounds(g.squareQuad('h', 1, 0))
+	test.That(t, h1, test.ShouldResemble, image.Rect(180, 50, 240, 110))
+
+	a8 := quadBounds(g.squareQuad('a', 8, 0))
+	test.That(t, a8, test.ShouldResemble, image.Rect(600, 470, 660, 530))
+}
+
+func TestDefaultBoardGridPortrait(t *testing.T) {
+	g := defaultBoardGrid(image.Rect(0, 0, 400, 600))
+
+	h1 := quadBounds(g.squareQuad('h', 1, 0))
+	test.That(t, h1, test.ShouldResemble, image.Rect(0, 100, 50, 150))
+}
+
+func TestBoardGridRoundTrip(t *testing.T) {
+	g, err := newBoardGrid([]image.Point{{210, 95}, {905, 130}, {870, 690}, {180, 640}})
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, uv := range [][2]float64{{0, 0}, {8, 8}, {3.5, 6.25}, {7.9, .1}} {
+		p := g.imagePoint(uv[0], uv[1])
+		u, v := g.boardPoint(p.x, p.y)
+		test.That(t, u, test.ShouldAlmostEqual, uv[0], 1e-6)
+		test.That(t, v, test.ShouldAlmostEqual, uv[1], 1e-6)
+	}
+
+	_, err = newBoardGrid([]image.Point{{0, 0}, {1, 0}, {1, 1}})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	_, err = newBoardGrid([]image.Point{{0, 0}, {100, 0}, {200, 0}, {300, 0}})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestBoardDebugImageSkewedBoard(t *testing.T) {
+	props := touch.RealSenseProperties
+	g, err := newBoardGrid([]image.Point{{300, 80}, {1010, 120}, {980, 700}, {260, 660}})
+	test.That(t, err, test.ShouldBeNil)
+
+	pc := syntheticBoardCloud(t, props, syntheticBoardFrame(t, props, g, 600), map[string]syntheticPiece{
+		"e2": {color.NRGBA{240, 240, 240, 255}, 40},
+		"d7": {color.NRGBA{20, 20, 20, 255}, 40},
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, props.IntrinsicParams.Width, props.IntrinsicParams.Height))
+
+	out, squares, err := boardDebugImage(img, pc, props, g, estimateBoardFrame(pc, props, g), squareOptions{inset: .1, maxHeight: 150})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out.Bounds().Dx(), test.ShouldEqual, out.Bounds().Dy())
+	test.That(t, len(squares), test.ShouldEqual, 64)
+
+	for _, s := range squares {
+		test.That(t, s.pc.Size(), test.ShouldBeGreaterThan, 0)
+		test.That(t, s.originalBounds, test.ShouldResemble, quadBounds(g.squareQuad(s.file, s.rank, 0)))
+
+		switch s.name {
+		case "e2":
+			test.That(t, s.color, test.ShouldEqual, 1)
+		case "d7":
+			test.That(t, s.color, test.ShouldEqual, 2)
+		default:
+			test.That(t, s.color, test.ShouldEqual, 0)
+		}
+	}
+}
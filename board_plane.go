@@ -0,0 +1,234 @@
+package viamchess
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+)
+
+// plane is normal·p + d = 0, with normal a unit vector pointing at the camera,
+// so distance is the height above the plane.
+type plane struct {
+	normal r3.Vector
+	d      float64
+}
+
+func planeFromPoints(a, b, c r3.Vector) (plane, bool) {
+	n := b.Sub(a).Cross(c.Sub(a))
+	if n.Norm() < 1e-9 {
+		return plane{}, false
+	}
+	return orientedPlane(n.Normalize(), a), true
+}
+
+// orientedPlane makes the plane through p with the normal flipped towards the camera at the origin.
+func orientedPlane(n, p r3.Vector) plane {
+	if n.Dot(p) > 0 {
+		n = n.Mul(-1)
+	}
+	return plane{normal: n, d: -n.Dot(p)}
+}
+
+func (pl plane) distance(p r3.Vector) float64 {
+	return pl.normal.Dot(p) + pl.d
+}
+
+// intersectRay finds where the ray from the camera along dir hits the plane.
+func (pl plane) intersectRay(dir r3.Vector) (r3.Vector, bool) {
+	denom := pl.normal.Dot(dir)
+	if math.Abs(denom) < 1e-9 {
+		return r3.Vector{}, false
+	}
+	t := -pl.d / denom
+	if t <= 0 {
+		return r3.Vector{}, false
+	}
+	return dir.Mul(t), true
+}
+
+// fitPlaneRANSAC finds the plane with the most points within threshold mm of it, then refines it
+// by least squares over those points. Returns the plane and how many points support it.
+func fitPlaneRANSAC(points []r3.Vector, iterations int, threshold float64, rng *rand.Rand) (plane, int, error) {
+	if len(points) < 3 {
+		return plane{}, 0, fmt.Errorf("need at least 3 points to fit a plane, got %d", len(points))
+	}
+
+	best := plane{}
+	bestCount := 0
+	for range iterations {
+		pl, ok := planeFromPoints(
+			points[rng.Intn(len(points))],
+			points[rng.Intn(len(points))],
+			points[rng.Intn(len(points))],
+		)
+		if !ok {
+			continue
+		}
+		count := countInliers(points, pl, threshold)
+		if count > bestCount {
+			best, bestCount = pl, count
+		}
+	}
+
+	if bestCount < 3 {
+		return plane{}, 0, fmt.Errorf("couldn't fit a plane to %d points", len(points))
+	}
+
+	for range 3 {
+		best = refinePlane(points, best, threshold)
+	}
+
+	return best, countInliers(points, best, threshold), nil
+}
+
+func countInliers(points []r3.Vector, pl plane, threshold float64) int {
+	count := 0
+	for _, p := range points {
+		if math.Abs(pl.distance(p)) <= threshold {
+			count++
+		}
+	}
+	return count
+}
+
+// refinePlane fits h = a*x + b*y + c by least squares over the inliers, in coordinates
+// aligned with the current plane, and tilts the plane to match.
+func refinePlane(points []r3.Vector, pl plane, threshold float64) plane {
+	e1 := pl.normal.Ortho()
+	e2 := pl.normal.Cross(e1)
+	center := pl.normal.Mul(-pl.d)
+
+	var sxx, sxy, syy, sx, sy, sxh, syh, sh, n float64
+	for _, p := range points {
+		h := pl.distance(p)
+		if math.Abs(h) > threshold {
+			continue
+		}
+		q := p.Sub(center)
+		x, y := e1.Dot(q), e2.Dot(q)
+		sxx += x * x
+		sxy += x * y
+		syy += y * y
+		sx += x
+		sy += y
+		sxh += x * h
+		syh += y * h
+		sh += h
+		n++
+	}
+
+	inv, ok := invert3([9]float64{
+		sxx, sxy, sx,
+		sxy, syy, sy,
+		sx, sy, n,
+	})
+	if !ok {
+		return pl
+	}
+
+	a := inv[0]*sxh + inv[1]*syh + inv[2]*sh
+	b := inv[3]*sxh + inv[4]*syh + inv[5]*sh
+	c := inv[6]*sxh + inv[7]*syh + inv[8]*sh
+
+	normal := pl.normal.Sub(e1.Mul(a)).Sub(e2.Mul(b)).Normalize()
+	return orientedPlane(normal, center.Add(pl.normal.Mul(c)))
+}
+
+// boardFrameFromPlane fits the board plane to the points inside the grid and puts the
+// corners where the camera rays through the grid corners hit it.
+func boardFrameFromPlane(pc pointcloud.PointCloud, props camera.Properties, grid *boardGrid) (*boardFrame, error) {
+	if props.IntrinsicParams == nil {
+		return nil, fmt.Errorf("camera does not have intrinsic parameters")
+	}
+
+	const maxPoints = 5000
+
+	points := []r3.Vector{}
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		x, y := props.IntrinsicParams.PointToPixel(p.X, p.Y, p.Z)
+		u, v := grid.boardPoint(x, y)
+		if u >= 0 && u <= 8 && v >= 0 && v <= 8 {
+			points = append(points, p)
+		}
+		return true
+	})
+
+	// deterministic so the same capture always gives the same answer
+	rng := rand.New(rand.NewSource(1))
+	if len(points) > maxPoints {
+		rng.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+		points = points[:maxPoints]
+	}
+
+	pl, count, err := fitPlaneRANSAC(points, 200, 4, rng)
+	if err != nil {
+		return nil, err
+	}
+	if count < len(points)/4 {
+		return nil, fmt.Errorf("board plane only fits %d of %d points", count, len(points))
+	}
+
+	corners := []r3.Vector{}
+	for _, c := range grid.corners {
+		x, y, z := props.IntrinsicParams.PixelToPoint(c.x, c.y, 1)
+		p, ok := pl.intersectRay(r3.Vector{X: x, Y: y, Z: z})
+		if !ok {
+			return nil, fmt.Errorf("board corner %v isn't on the board plane", c)
+		}
+		corners = append(corners, p)
+	}
+
+	return newBoardFrame(corners)
+}
+
+// estimatePiece classifies a square from how high its points are above the board.
+// Returns 0 - blank, 1 - white, 2 - black, and the piece height in mm.
+func estimatePiece(pc pointcloud.PointCloud, frame *boardFrame) (int, float64) {
+	var totalR, totalG, totalB float64
+	count := 0
+	heights := []float64{}
+
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		_, _, h := frame.toBoard(p)
+		if h < minPieceSize {
+			return true
+		}
+		heights = append(heights, h)
+		if d != nil && d.HasColor() {
+			r, g, b := d.RGB255()
+			totalR += float64(r)
+			totalG += float64(g)
+			totalB += float64(b)
+			count++
+		}
+		return true
+	})
+
+	if len(heights) <= 10 {
+		return 0, 0 // blank - no piece detected
+	}
+
+	height := percentile(heights, .95)
+
+	if count == 0 {
+		return 1, height // no color to go on, call it white
+	}
+
+	brightness := (totalR + totalG + totalB) / float64(count) / 3.0
+	if brightness > 128 {
+		return 1, height
+	}
+	return 2, height
+}
+
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
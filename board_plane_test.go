@@ -0,0 +1,93 @@
+package viamchess
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/test"
+
+	"github.com/erh/vmodutils/touch"
+)
+
+func TestFitPlaneRANSAC(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+
+	// z = 500 + .2x - .1y with some noise, plus a pile of points 50mm above it
+	points := []r3.Vector{}
+	for i := 0; i < 800; i++ {
+		x, y := rng.Float64()*400-200, rng.Float64()*400-200
+		z := 500 + .2*x - .1*y + rng.NormFloat64()
+		points = append(points, r3.Vector{X: x, Y: y, Z: z})
+	}
+	for i := 0; i < 200; i++ {
+		x, y := rng.Float64()*50, rng.Float64()*50
+		points = append(points, r3.Vector{X: x, Y: y, Z: 450 + .2*x - .1*y})
+	}
+
+	pl, count, err := fitPlaneRANSAC(points, 200, 4, rng)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, count, test.ShouldBeGreaterThan, 750)
+	test.That(t, count, test.ShouldBeLessThan, 810)
+
+	want := r3.Vector{X: .2, Y: -.1, Z: -1}.Normalize()
+	test.That(t, pl.normal.Dot(want), test.ShouldBeGreaterThan, math.Cos(math.Pi/180))
+	test.That(t, pl.distance(r3.Vector{Z: 500}), test.ShouldAlmostEqual, 0, 1)
+	test.That(t, pl.distance(r3.Vector{Z: 450}), test.ShouldBeGreaterThan, 0) // closer to the camera is up
+
+	_, _, err = fitPlaneRANSAC(points[:2], 10, 4, rng)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestBoardFrameFromPlane(t *testing.T) {
+	props := touch.RealSenseProperties
+	g, want := syntheticTiltedBoard(t, props, 650, 30)
+
+	pc := syntheticBoardCloud(t, props, want, map[string]syntheticPiece{
+		"a1": {color.NRGBA{240, 240, 240, 255}, 60},
+		"e4": {color.NRGBA{240, 240, 240, 255}, 60},
+	})
+
+	f, err := boardFrameFromPlane(pc, props, g)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, f.normal.Dot(want.normal), test.ShouldBeGreaterThan, math.Cos(math.Pi/180))
+	test.That(t, f.origin.Distance(want.origin), test.ShouldBeLessThan, 5)
+	test.That(t, f.squareSize(), test.ShouldAlmostEqual, 45, 1)
+}
+
+func TestObliqueBoardPieces(t *testing.T) {
+	props := touch.RealSenseProperties
+	g, frame := syntheticTiltedBoard(t, props, 650, 35)
+
+	pc := syntheticBoardCloud(t, props, frame, map[string]syntheticPiece{
+		"e2": {color.NRGBA{240, 240, 240, 255}, 45},
+		"c6": {color.NRGBA{20, 20, 20, 255}, 80},
+		"h8": {color.NRGBA{20, 20, 20, 255}, 60},
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, props.IntrinsicParams.Width, props.IntrinsicParams.Height))
+	_, squares, err := boardDebugImage(img, pc, props, g, estimateBoardFrame(pc, props, g), squareOptions{inset: .1, maxHeight: 150})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(squares), test.ShouldEqual, 64)
+
+	for _, s := range squares {
+		switch s.name {
+		case "e2":
+			test.That(t, s.color, test.ShouldEqual, 1)
+			test.That(t, s.height, test.ShouldAlmostEqual, 45, 3)
+		case "c6":
+			test.That(t, s.color, test.ShouldEqual, 2)
+			test.That(t, s.height, test.ShouldAlmostEqual, 80, 3)
+		case "h8":
+			test.That(t, s.color, test.ShouldEqual, 2)
+			test.That(t, s.height, test.ShouldAlmostEqual, 60, 3)
+		default:
+			test.That(t, s.color, test.ShouldEqual, 0)
+			test.That(t, s.height, test.ShouldEqual, 0)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package viamchess
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+const boardThickness = 10.0
+
+// boardPose is the board in the world: origin at the outside corner of a1, x along the files
+// towards h, y along the ranks towards 8, z up out of the board.
+type boardPose struct {
+	pose       spatialmath.Pose
+	squareSize float64 // mm
+}
+
+// a1Pose is the board pose in the camera frame.
+func (f *boardFrame) a1Pose() (spatialmath.Pose, error) {
+	z := f.normal
+	x := f.uAxis.Mul(-1)
+	x = x.Sub(z.Mul(z.Dot(x))).Normalize()
+	y := z.Cross(x)
+
+	rm, err := spatialmath.NewRotationMatrix([]float64{
+		x.X, y.X, z.X,
+		x.Y, y.Y, z.Y,
+		x.Z, y.Z, z.Z,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return spatialmath.NewPose(f.fromBoard(8, 0, 0), rm), nil
+}
+
+// point converts board coordinates in mm to the world.
+func (b *boardPose) point(x, y, z float64) r3.Vector {
+	return spatialmath.Compose(b.pose, spatialmath.NewPoseFromPoint(r3.Vector{X: x, Y: y, Z: z})).Point()
+}
+
+// squareSpot is the middle of a square in board coordinates.
+func (b *boardPose) squareSpot(name string) (float64, float64, error) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return 0, 0, fmt.Errorf("bad square name (%s)", name)
+	}
+	return (float64(name[0]-'a') + .5) * b.squareSize, (float64(name[1]-'1') + .5) * b.squareSize, nil
+}
+
+// squareCenter is the world position of the middle of a square, on the board surface.
+func (b *boardPose) squareCenter(name string) (r3.Vector, error) {
+	x, y, err := b.squareSpot(name)
+	if err != nil {
+		return r3.Vector{}, err
+	}
+	return b.point(x, y, 0), nil
+}
+
+// geometry is the board as a box, in the board frame, with its top at z=0.
+func (b *boardPose) geometry() (spatialmath.Geometry, error) {
+	side := 8 * b.squareSize
+	return spatialmath.NewBox(
+		spatialmath.NewPoseFromPoint(r3.Vector{X: side / 2, Y: side / 2, Z: -boardThickness / 2}),
+		r3.Vector{X: side, Y: side, Z: boardThickness},
+		"board",
+	)
+}
+
+// link publishes the board as a frame named "board" under world.
+func (b *boardPose) link() (*referenceframe.LinkInFrame, error) {
+	g, err := b.geometry()
+	if err != nil {
+		return nil, err
+	}
+	return referenceframe.NewLinkInFrame(referenceframe.World, b.pose, "board", g), nil
+}
+
+func (b *boardPose) toMap() map[string]interface{} {
+	side := 8 * b.squareSize
+	return map[string]interface{}{
+		"frame": map[string]interface{}{
+			"parent": referenceframe.World,
+			"pose":   poseToMap(b.pose),
+		},
+		"square_size": b.squareSize,
+		"geometry": map[string]interface{}{
+			"type": "box",
+			"x":    side,
+			"y":    side,
+			"z":    boardThickness,
+		},
+	}
+}
+
+func boardPoseFromMap(m map[string]interface{}) (*boardPose, error) {
+	frame, ok := m["frame"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no frame in board %v", m)
+	}
+	pm, ok := frame["pose"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no pose in board frame %v", frame)
+	}
+	pose, err := poseFromMap(pm)
+	if err != nil {
+		return nil, err
+	}
+	size, ok := m["square_size"].(float64)
+	if !ok || size <= 0 {
+		return nil, fmt.Errorf("bad square_size in board %v", m)
+	}
+	return &boardPose{pose: pose, squareSize: size}, nil
+}
+
+func poseToMap(p spatialmath.Pose) map[string]interface{} {
+	pt := p.Point()
+	o := p.Orientation().OrientationVectorDegrees()
+	return map[string]interface{}{
+		"x":     pt.X,
+		"y":     pt.Y,
+		"z":     pt.Z,
+		"o_x":   o.OX,
+		"o_y":   o.OY,
+		"o_z":   o.OZ,
+		"theta": o.Theta,
+	}
+}
+
+func poseFromMap(m map[string]interface{}) (spatialmath.Pose, error) {
+	v := map[string]float64{}
+	for _, k := range []string{"x", "y", "z", "o_x", "o_y", "o_z", "theta"} {
+		f, ok := m[k].(float64)
+		if !ok {
+			return nil, fmt.Errorf("pose missing %s: %v", k, m)
+		}
+		v[k] = f
+	}
+	return spatialmath.NewPose(
+		r3.Vector{X: v["x"], Y: v["y"], Z: v["z"]},
+		&spatialmath.OrientationVectorDegrees{OX: v["o_x"], OY: v["o_y"], OZ: v["o_z"], Theta: v["theta"]},
+	), nil
+}
+
+// smoothBoardPose blends a new estimate into the previous one so the board model doesn't jitter
+// between captures. A big jump means the board moved, so the new estimate wins outright.
+func smoothBoardPose(prev, next *boardPose) *boardPose {
+	if prev == nil {
+		return next
+	}
+
+	const (
+		maxDrift = 20.0 // mm
+		alpha    = .2
+	)
+
+	if prev.pose.Point().Distance(next.pose.Point()) > maxDrift {
+		return next
+	}
+
+	return &boardPose{
+		pose:       spatialmath.Interpolate(prev.pose, next.pose, alpha),
+		squareSize: prev.squareSize*(1-alpha) + next.squareSize*alpha,
+	}
+}
@@ -0,0 +1,71 @@
+package viamchess
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+
+	"github.com/erh/vmodutils/touch"
+)
+
+func TestBoardFrameA1Pose(t *testing.T) {
+	props := touch.RealSenseProperties
+	_, f := syntheticTiltedBoard(t, props, 650, 20)
+
+	pose, err := f.a1Pose()
+	test.That(t, err, test.ShouldBeNil)
+
+	b := &boardPose{pose: pose, squareSize: f.squareSize()}
+
+	a1, err := b.squareCenter("a1")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, a1.Distance(f.fromBoard(7.5, .5, 0)), test.ShouldBeLessThan, 1e-6)
+
+	h8, err := b.squareCenter("h8")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, h8.Distance(f.fromBoard(.5, 7.5, 0)), test.ShouldBeLessThan, 1e-6)
+
+	// z is up, towards the camera
+	up := b.point(0, 0, 10).Sub(b.point(0, 0, 0))
+	test.That(t, up.Distance(f.normal.Mul(10)), test.ShouldBeLessThan, 1e-6)
+
+	_, err = b.squareCenter("i9")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestBoardPoseMapRoundTrip(t *testing.T) {
+	b := &boardPose{
+		pose:       spatialmath.NewPose(r3.Vector{X: 300, Y: -200, Z: 5}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90}),
+		squareSize: 45,
+	}
+
+	b2, err := boardPoseFromMap(b.toMap())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, spatialmath.PoseAlmostEqual(b.pose, b2.pose), test.ShouldBeTrue)
+	test.That(t, b2.squareSize, test.ShouldEqual, 45)
+
+	// theta 90 about z: files run along world y
+	h1, err := b2.squareCenter("h1")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, h1.Distance(r3.Vector{X: 300 - 22.5, Y: -200 + 7.5*45, Z: 5}), test.ShouldBeLessThan, 1e-6)
+
+	_, err = boardPoseFromMap(map[string]interface{}{"square_size": 45.0})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestSmoothBoardPose(t *testing.T) {
+	prev := &boardPose{pose: spatialmath.NewPoseFromPoint(r3.Vector{X: 100}), squareSize: 45}
+
+	next := smoothBoardPose(prev, &boardPose{pose: spatialmath.NewPoseFromPoint(r3.Vector{X: 110}), squareSize: 50})
+	test.That(t, next.pose.Point().X, test.ShouldAlmostEqual, 102)
+	test.That(t, next.squareSize, test.ShouldAlmostEqual, 46)
+
+	// the board got moved
+	next = smoothBoardPose(prev, &boardPose{pose: spatialmath.NewPoseFromPoint(r3.Vector{X: 200}), squareSize: 45})
+	test.That(t, next.pose.Point().X, test.ShouldAlmostEqual, 200)
+
+	test.That(t, smoothBoardPose(nil, prev), test.ShouldEqual, prev)
+}
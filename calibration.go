@@ -0,0 +1,345 @@
+package viamchess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	"github.com/corentings/chess/v2"
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/vision/viscapture"
+	"go.viam.com/utils/trace"
+
+	"github.com/erh/vmodutils/touch"
+)
+
+// defaultCalibrationSquares are the corners and a ring inside them, so the fit sees the whole board.
+var defaultCalibrationSquares = []string{"a1", "h1", "h8", "a8", "c3", "f3", "f6", "c6"}
+
+type CalibrateCmd struct {
+	Probe   string   // square the probe piece starts on, everything in Squares has to be empty
+	Squares []string // where to put it down, defaults to defaultCalibrationSquares
+	Clear   bool     // forget the calibration
+}
+
+// calibration corrects where vision says something is to where the arm has to go for it:
+// x' = a x + b y + c, y' = d x + e y + f, z' = z + ZOffset.
+type calibration struct {
+	Affine  [6]float64 `json:"affine"`
+	ZOffset float64    `json:"z-offset"`
+	Points  int        `json:"points"`
+	RMS     float64    `json:"rms-mm"` // what's left over after the fit
+}
+
+func (c *calibration) apply(v r3.Vector) r3.Vector {
+	if c == nil {
+		return v
+	}
+	a := c.Affine
+	return r3.Vector{
+		X: a[0]*v.X + a[1]*v.Y + a[2],
+		Y: a[3]*v.X + a[4]*v.Y + a[5],
+		Z: v.Z + c.ZOffset,
+	}
+}
+
+func (c *calibration) toMap() map[string]interface{} {
+	if c == nil {
+		return map[string]interface{}{"calibrated": false}
+	}
+	return map[string]interface{}{
+		"calibrated": true,
+		"affine":     c.Affine[:],
+		"z_offset":   c.ZOffset,
+		"points":     c.Points,
+		"rms_mm":     c.RMS,
+	}
+}
+
+// calibrationPoint is one probe drop: where the arm put it, and where the camera saw it after.
+type calibrationPoint struct {
+	arm, seen r3.Vector
+}
+
+// solve3 solves m x = v, for the fit's normal equations.
+func solve3(m [3][3]float64, v [3]float64) ([3]float64, bool) {
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det(m)
+	if math.Abs(d) < 1e-9 {
+		return [3]float64{}, false
+	}
+	out := [3]float64{}
+	for i := range 3 {
+		mi := m
+		for r := range 3 {
+			mi[r][i] = v[r]
+		}
+		out[i] = det(mi) / d
+	}
+	return out, true
+}
+
+// fitCalibration is the least squares affine map from seen to arm. zOffset is worked out separately,
+// from the grabs, since the drops all end up at the same height.
+func fitCalibration(points []calibrationPoint, zOffset float64) (*calibration, error) {
+	if len(points) < 3 {
+		return nil, fmt.Errorf("need at least 3 points to calibrate, have %d", len(points))
+	}
+
+	m := [3][3]float64{}
+	bx, by := [3]float64{}, [3]float64{}
+	for _, p := range points {
+		row := [3]float64{p.seen.X, p.seen.Y, 1}
+		for i := range 3 {
+			for j := range 3 {
+				m[i][j] += row[i] * row[j]
+			}
+			bx[i] += row[i] * p.arm.X
+			by[i] += row[i] * p.arm.Y
+		}
+	}
+
+	px, ok := solve3(m, bx)
+	if !ok {
+		return nil, fmt.Errorf("calibration points are all in a line")
+	}
+	py, _ := solve3(m, by)
+
+	c := &calibration{
+		Affine:  [6]float64{px[0], px[1], px[2], py[0], py[1], py[2]},
+		ZOffset: zOffset,
+		Points:  len(points),
+	}
+
+	sum := 0.0
+	for _, p := range points {
+		got := c.apply(p.seen)
+		sum += math.Pow(got.X-p.arm.X, 2) + math.Pow(got.Y-p.arm.Y, 2)
+	}
+	c.RMS = math.Sqrt(sum / float64(len(points)))
+	return c, nil
+}
+
+func readCalibration(fn string) (*calibration, error) {
+	data, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read calibration (%s): %w", fn, err)
+	}
+
+	c := &calibration{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, fmt.Errorf("bad calibration (%s): %w", fn, err)
+	}
+	return c, nil
+}
+
+func writeCalibration(fn string, c *calibration) error {
+	if c == nil {
+		err := os.Remove(fn)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fn, b)
+}
+
+// probeTop is where the camera sees the top of whatever's on a square.
+func (s *viamChessChess) probeTop(data viscapture.VisCapture, pos string) (r3.Vector, error) {
+	o := s.findObject(data, pos)
+	if o == nil {
+		return r3.Vector{}, fmt.Errorf("can't find object for: %s", pos)
+	}
+	if o.Size() == 0 {
+		return r3.Vector{}, fmt.Errorf("nothing seen on %s", pos)
+	}
+	return touch.PCFindHighestInRegion(o, image.Rect(-1000, -1000, 1000, 1000)), nil
+}
+
+// doCalibrate moves the probe to each square with no correction, and looks where it ended up. What the
+// camera sees against where the arm was is the fit. The probe goes back where it started after.
+func (s *viamChessChess) doCalibrate(ctx context.Context, cmd CalibrateCmd) (map[string]interface{}, error) {
+	ctx, span := trace.StartSpan(ctx, "doCalibrate")
+	defer span.End()
+
+	s.stopPlay()
+	s.stopExhibition()
+
+	s.doCommandLock.Lock()
+	defer s.doCommandLock.Unlock()
+
+	if cmd.Clear || cmd.Probe != "" {
+		s.joints.clear() // where squares are is changing, plan everything again
+	}
+
+	defer func() {
+		err := s.goToStart(ctx)
+		if err != nil {
+			s.logger.Warnf("can't go home: %v", err)
+		}
+	}()
+
+	if cmd.Clear {
+		s.calibration.Store(nil)
+		return s.calibration.Load().toMap(), writeCalibration(s.calibrationFile, nil)
+	}
+
+	if cmd.Probe == "" {
+		return s.calibration.Load().toMap(), nil
+	}
+
+	squares := cmd.Squares
+	if len(squares) == 0 {
+		squares = defaultCalibrationSquares
+	}
+	for _, sq := range append([]string{cmd.Probe}, squares...) {
+		if _, ok := parseSquare(sq); !ok {
+			return nil, fmt.Errorf("bad square (%s)", sq)
+		}
+	}
+
+	// the board's meant to be clear, but the graveyard still has to be planned around
+	game, err := s.getGame(ctx)
+	if err != nil {
+		return nil, err
+	}
+	around := graveyardOnly(game.graveyard)
+
+	err = s.goToStart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.capture(ctx)
+	if err != nil {
+		return nil, err
+	}
+	colors, err := s.boardColors(all)
+	if err != nil {
+		return nil, err
+	}
+	probe, _ := parseSquare(cmd.Probe)
+	if colors[probe] == 0 {
+		return nil, fmt.Errorf("no probe on %s", cmd.Probe)
+	}
+	for _, name := range squares {
+		sq, _ := parseSquare(name)
+		if sq != probe && colors[sq] != 0 {
+			return nil, fmt.Errorf("%s has to be empty to calibrate", name)
+		}
+	}
+
+	// everything from here is in raw vision coordinates
+	old := s.calibration.Swap(nil)
+	restore := true
+	defer func() {
+		if restore {
+			s.calibration.Store(old)
+		}
+	}()
+
+	points := []calibrationPoint{}
+	zSum := 0.0
+	at := cmd.Probe
+	for _, target := range squares {
+		if target == at {
+			continue
+		}
+
+		top, err := s.probeTop(all, at)
+		if err != nil {
+			return nil, err
+		}
+		dest, err := s.getCenterFor(all, target, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		grabZ, err := s.probe(ctx, all, around, at, target, dest)
+		if err != nil {
+			return nil, fmt.Errorf("calibrating %s -> %s: %w", at, target, err)
+		}
+		zSum += grabZ - top.Z
+		at = target
+
+		err = s.goToStart(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all, err = s.capture(ctx)
+		if err != nil {
+			return nil, err
+		}
+		seen, err := s.probeTop(all, target)
+		if err != nil {
+			return nil, fmt.Errorf("probe didn't land on %s: %w", target, err)
+		}
+		s.logger.Infof("calibration %s: arm %v seen %v", target, dest, seen)
+		points = append(points, calibrationPoint{arm: dest, seen: seen})
+	}
+
+	c, err := fitCalibration(points, zSum/float64(len(points)))
+	if err != nil {
+		return nil, err
+	}
+	err = writeCalibration(s.calibrationFile, c)
+	if err != nil {
+		return nil, err
+	}
+	restore = false
+	s.calibration.Store(c)
+	s.logger.Infof("calibrated from %d points, %.1fmm rms", c.Points, c.RMS)
+
+	if at != cmd.Probe {
+		err = s.transfer(ctx, all, around, at, cmd.Probe, chess.NoPiece, nil)
+		if err != nil {
+			return nil, fmt.Errorf("calibrated, but can't put the probe back: %w", err)
+		}
+	}
+
+	return c.toMap(), nil
+}
+
+// probe picks up the probe at from and puts it down at dest, to's spot as vision has it, returning how high it got grabbed.
+func (s *viamChessChess) probe(ctx context.Context, data viscapture.VisCapture, theState *state, from, to string, dest r3.Vector) (float64, error) {
+	center, err := s.getCenterFor(data, from, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	ws, err := s.worldState(data, theState, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	grabZ, err := s.grasp(ctx, chess.NoPiece, from, center, ws)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.travelDown(ctx, r3.Vector{X: dest.X, Y: dest.Y, Z: grabZ}, ws)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.hand.prepare(ctx, s.conf.graspProfile(chess.NoPiece).OpenWidth)
+	if err != nil {
+		return 0, err
+	}
+	return grabZ, s.goUp(ctx, r3.Vector{X: dest.X, Y: dest.Y, Z: grabZ}, ws)
+}
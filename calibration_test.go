@@ -0,0 +1,61 @@
+package viamchess
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/test"
+)
+
+func TestFitCalibration(t *testing.T) {
+	// camera is off by a small rotation, a scale and a shift
+	truth := &calibration{Affine: [6]float64{.99, -.02, 4, .02, 1.01, -7}}
+
+	points := []calibrationPoint{}
+	for _, seen := range []r3.Vector{{X: 300, Y: -200}, {X: 600, Y: -200}, {X: 600, Y: 100}, {X: 300, Y: 100}, {X: 450, Y: -50}} {
+		points = append(points, calibrationPoint{arm: truth.apply(seen), seen: seen})
+	}
+
+	c, err := fitCalibration(points, -3)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, c.Points, test.ShouldEqual, 5)
+	test.That(t, c.RMS, test.ShouldAlmostEqual, 0, .001)
+	for i := range c.Affine {
+		test.That(t, c.Affine[i], test.ShouldAlmostEqual, truth.Affine[i], .0001)
+	}
+	got := c.apply(r3.Vector{X: 500, Y: 0, Z: 40})
+	test.That(t, got.Z, test.ShouldEqual, 37.0)
+
+	_, err = fitCalibration(points[:2], 0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	line := []calibrationPoint{{r3.Vector{X: 1}, r3.Vector{X: 1}}, {r3.Vector{X: 2}, r3.Vector{X: 2}}, {r3.Vector{X: 3}, r3.Vector{X: 3}}}
+	_, err = fitCalibration(line, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	var none *calibration
+	test.That(t, none.apply(r3.Vector{X: 1, Y: 2, Z: 3}), test.ShouldResemble, r3.Vector{X: 1, Y: 2, Z: 3})
+	test.That(t, none.toMap()["calibrated"], test.ShouldBeFalse)
+}
+
+func TestCalibrationFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "calibration.json")
+
+	c, err := readCalibration(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, c, test.ShouldBeNil)
+
+	in := &calibration{Affine: [6]float64{1, 0, 2, 0, 1, 3}, ZOffset: -4, Points: 8, RMS: 1.5}
+	test.That(t, writeCalibration(fn, in), test.ShouldBeNil)
+	c, err = readCalibration(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, c, test.ShouldResemble, in)
+
+	test.That(t, writeCalibration(fn, nil), test.ShouldBeNil)
+	c, err = readCalibration(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, c, test.ShouldBeNil)
+	test.That(t, writeCalibration(fn, nil), test.ShouldBeNil)
+}
@@ -0,0 +1,264 @@
+package viamchess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/services/motion"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/vision/viscapture"
+	"go.viam.com/utils/trace"
+)
+
+const (
+	centerToleranceMM  = 3.0
+	centerToleranceDeg = 1.0
+	centerGain         = .5 // how much of the error to take out each step, all of it overshoots
+	maxCenterSteps     = 12
+
+	saveStartSwitch = "switch" // pose-start's position 1 saves where the arm is
+	saveStartData   = "data"   // start_pose.json in the module data, used instead of pose-start after
+)
+
+// savedStart is a start pose found by centering, kept in module data.
+type savedStart struct {
+	Joints []referenceframe.Input `json:"joints"`
+	Pose   map[string]interface{} `json:"pose"` // just so a person can read it
+}
+
+func readSavedStart(fn string) (*savedStart, error) {
+	data, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read start pose (%s): %w", fn, err)
+	}
+
+	ss := &savedStart{}
+	err = json.Unmarshal(data, ss)
+	if err != nil {
+		return nil, fmt.Errorf("bad start pose (%s): %w", fn, err)
+	}
+	if len(ss.Joints) == 0 {
+		return nil, fmt.Errorf("start pose (%s) has no joints", fn)
+	}
+	return ss, nil
+}
+
+// boardSkew is how far off square the board sits in the image, in degrees, -45 to 45.
+func boardSkew(ax, ay, hx, hy float64) float64 {
+	angle := math.Atan2(hy-ay, hx-ax) * 180 / math.Pi
+	return angle - 90*math.Round(angle/90)
+}
+
+// detectionCenter is the middle of a square in the image, in pixels.
+func (s *viamChessChess) detectionCenter(data viscapture.VisCapture, pos string) (r3.Vector, bool) {
+	d := s.findDetection(data, pos)
+	if d == nil || d.BoundingBox() == nil {
+		return r3.Vector{}, false
+	}
+	b := d.BoundingBox()
+	return r3.Vector{X: float64(b.Min.X+b.Max.X) / 2, Y: float64(b.Min.Y+b.Max.Y) / 2}, true
+}
+
+// squareWorld is where a square's center is, without the piece on it getting in the way.
+func (s *viamChessChess) squareWorld(data viscapture.VisCapture, pos string) (r3.Vector, bool) {
+	if s.board != nil {
+		v, err := s.board.squareCenter(pos)
+		return v, err == nil
+	}
+	o := s.findObject(data, pos)
+	if o == nil {
+		return r3.Vector{}, false
+	}
+	md := o.MetaData()
+	return md.Center(), true
+}
+
+// offCenter is how far, in world mm, the board's center is from the middle of the image, and how
+// many degrees the board is turned in it. The middle of the image is found by fitting every square's
+// pixels to where it is in the world.
+func (s *viamChessChess) offCenter(data viscapture.VisCapture) (r3.Vector, float64, error) {
+	if data.Image == nil {
+		return r3.Vector{}, 0, fmt.Errorf("no image to center on")
+	}
+
+	points := []calibrationPoint{}
+	pixels := map[string]r3.Vector{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		name := sq.String()
+		px, ok := s.detectionCenter(data, name)
+		if !ok {
+			continue
+		}
+		w, ok := s.squareWorld(data, name)
+		if !ok {
+			continue
+		}
+		pixels[name] = px
+		points = append(points, calibrationPoint{arm: w, seen: px})
+	}
+
+	toWorld, err := fitCalibration(points, 0)
+	if err != nil {
+		return r3.Vector{}, 0, fmt.Errorf("can't place the image on the board: %w", err)
+	}
+
+	b := data.Image.Bounds()
+	looking := toWorld.apply(r3.Vector{X: float64(b.Min.X+b.Max.X) / 2, Y: float64(b.Min.Y+b.Max.Y) / 2})
+
+	center := r3.Vector{}
+	for _, pos := range []string{"d1", "e1", "d8", "e8"} {
+		w, ok := s.squareWorld(data, pos)
+		if !ok {
+			return r3.Vector{}, 0, fmt.Errorf("can't find %s", pos)
+		}
+		center = center.Add(w)
+	}
+	center = center.Mul(.25)
+
+	for _, pos := range []string{"a1", "a8", "h1", "h8"} {
+		if _, ok := pixels[pos]; !ok {
+			return r3.Vector{}, 0, fmt.Errorf("can't see %s", pos)
+		}
+	}
+	a := pixels["a1"].Add(pixels["a8"]).Mul(.5)
+	h := pixels["h1"].Add(pixels["h8"]).Mul(.5)
+
+	return r3.Vector{X: center.X - looking.X, Y: center.Y - looking.Y}, boardSkew(a.X, a.Y, h.X, h.Y), nil
+}
+
+// centerCamera moves the start pose over the board, and turns it, until the board is in the middle
+// of the image and square to it. The turn's direction isn't known up front, so it flips if it makes it worse.
+// save is where to keep the new start pose: switch, data, or nowhere. Unless it's kept, the arm goes back
+// to the old start pose after.
+func (s *viamChessChess) centerCamera(ctx context.Context, save string) (map[string]interface{}, error) {
+	ctx, span := trace.StartSpan(ctx, "centerCamera")
+	defer span.End()
+
+	if save != "" && save != saveStartSwitch && save != saveStartData {
+		return nil, fmt.Errorf("bad save (%s), need switch or data", save)
+	}
+
+	err := s.goToStart(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cached joints all have the old start's theta
+	s.joints.clear()
+
+	kept := false // at a start pose that was saved, or already back at the old one
+	defer func() {
+		if kept {
+			return
+		}
+		err := s.goHome(ctx, true)
+		if err != nil {
+			s.logger.Warnf("can't go back to the start pose: %v", err)
+		}
+	}()
+
+	turn := 1.0
+	lastSkew := math.Inf(1)
+	for step := 0; ; step++ {
+		time.Sleep(time.Second)
+
+		all, err := s.capture(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		off, skew, err := s.offCenter(all)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Infof("center step %d: off by %v mm, turned %0.1f degrees", step, off, skew)
+
+		if off.Norm() < centerToleranceMM && math.Abs(skew) < centerToleranceDeg {
+			res := map[string]interface{}{
+				"steps":  step,
+				"off_mm": off.Norm(),
+				"skew":   skew,
+				"pose":   poseToMap(s.startPose.Pose()),
+			}
+			if save == "" {
+				kept = true
+				return res, s.goHome(ctx, true)
+			}
+			err = s.saveStart(ctx, save)
+			kept = err == nil
+			return res, err
+		}
+		if step >= maxCenterSteps {
+			return nil, fmt.Errorf("couldn't center after %d steps, still off by %0.1f mm, %0.1f degrees", step, off.Norm(), skew)
+		}
+
+		if math.Abs(skew) > math.Abs(lastSkew) {
+			turn = -turn
+		}
+		lastSkew = skew
+
+		pose := s.startPose.Pose()
+		o := pose.Orientation().OrientationVectorDegrees()
+		o.Theta += turn * skew * centerGain
+		pose = spatialmath.NewPose(pose.Point().Add(off.Mul(centerGain)), o)
+
+		s.atStart = false
+		_, err = s.motion.Move(ctx, motion.MoveReq{
+			ComponentName: s.conf.Gripper,
+			Destination:   referenceframe.NewPoseInFrame("world", pose),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't move to %v: %w", pose, err)
+		}
+
+		s.startPose, err = s.rfs.GetPose(ctx, s.conf.Gripper, "world", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// saveStart keeps where the arm is now as the start pose. Saving to the switch forgets the one in
+// the data dir, otherwise that would still win.
+func (s *viamChessChess) saveStart(ctx context.Context, save string) error {
+	switch save {
+	case saveStartSwitch:
+		s.logger.Infof("saving start pose to %s", s.conf.PoseStart)
+		err := s.poseStart.SetPosition(ctx, 1, nil)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(s.startFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.startJoints = nil
+	case saveStartData:
+		joints, err := s.arm.JointPositions(ctx, nil)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(&savedStart{Joints: joints, Pose: poseToMap(s.startPose.Pose())}, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = writeFileAtomic(s.startFile, b)
+		if err != nil {
+			return err
+		}
+		s.logger.Infof("saved start pose to %s", s.startFile)
+		s.startJoints = joints
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package viamchess
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	toggleswitch "go.viam.com/rdk/components/switch"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/viscapture"
+	"go.viam.com/test"
+)
+
+func TestBoardSkew(t *testing.T) {
+	test.That(t, boardSkew(0, 0, 100, 0), test.ShouldAlmostEqual, 0)
+	test.That(t, boardSkew(0, 0, 100, 10), test.ShouldAlmostEqual, 5.71, .01)
+	test.That(t, boardSkew(0, 0, 0, 100), test.ShouldAlmostEqual, 0) // a quarter turn is still square
+	test.That(t, boardSkew(0, 0, -100, 10), test.ShouldAlmostEqual, -5.71, .01)
+}
+
+func TestOffCenter(t *testing.T) {
+	s := &viamChessChess{
+		logger: logging.NewTestLogger(t),
+		board:  &boardPose{pose: spatialmath.NewZeroPose(), squareSize: 50},
+	}
+
+	// 2 pixels a mm, looking at 200, 225 on the table
+	data := viscapture.VisCapture{Image: image.NewGray(image.Rect(0, 0, 1000, 1000))}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		w, err := s.board.squareCenter(sq.String())
+		test.That(t, err, test.ShouldBeNil)
+		x, y := int(w.X*2+100), int(w.Y*2+50)
+		data.Detections = append(data.Detections,
+			objectdetection.NewDetectionWithoutImgBounds(image.Rect(x-10, y-10, x+10, y+10), 1, sq.String()+"-0"))
+	}
+
+	off, skew, err := s.offCenter(data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, off.X, test.ShouldAlmostEqual, 0, .01)
+	test.That(t, off.Y, test.ShouldAlmostEqual, -25, .01)
+	test.That(t, skew, test.ShouldAlmostEqual, 0, .01)
+
+	data.Detections = data.Detections[:8]
+	_, _, err = s.offCenter(data)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestSavedStart(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "start_pose.json")
+
+	ss, err := readSavedStart(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ss, test.ShouldBeNil)
+
+	test.That(t, os.WriteFile(fn, []byte(`{"joints": [0, -0.2, -1.57, 0, 1.74, 3.07]}`), 0o644), test.ShouldBeNil)
+	ss, err = readSavedStart(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(ss.Joints), test.ShouldEqual, 6)
+
+	test.That(t, os.WriteFile(fn, []byte(`{"joints": []}`), 0o644), test.ShouldBeNil)
+	_, err = readSavedStart(fn)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+type recordingSwitch struct {
+	toggleswitch.Switch
+	set []uint32
+}
+
+func (sw *recordingSwitch) SetPosition(ctx context.Context, position uint32, extra map[string]interface{}) error {
+	sw.set = append(sw.set, position)
+	return nil
+}
+
+func TestSaveStartSwitch(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "start_pose.json")
+	test.That(t, os.WriteFile(fn, []byte(`{"joints": [0, -0.2, -1.57, 0, 1.74, 3.07]}`), 0o644), test.ShouldBeNil)
+
+	sw := &recordingSwitch{}
+	s := &viamChessChess{
+		logger:      logging.NewTestLogger(t),
+		conf:        &ChessConfig{},
+		poseStart:   sw,
+		startFile:   fn,
+		startJoints: []referenceframe.Input{0, -0.2, -1.57, 0, 1.74, 3.07},
+	}
+
+	// the switch only gets used if the saved joints are gone
+	test.That(t, s.saveStart(context.Background(), saveStartSwitch), test.ShouldBeNil)
+	test.That(t, sw.set, test.ShouldResemble, []uint32{1})
+	test.That(t, s.startJoints, test.ShouldBeNil)
+	_, err := os.Stat(fn)
+	test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+
+	// and again, with nothing to remove
+	test.That(t, s.saveStart(context.Background(), saveStartSwitch), test.ShouldBeNil)
+}
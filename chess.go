@@ -2,12 +2,14 @@ package viamchess
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"image"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/multierr"
@@ -20,6 +22,7 @@ import (
 	"go.viam.com/rdk/components/gripper"
 	toggleswitch "go.viam.com/rdk/components/switch"
 	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/motionplan"
 	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/robot/framesystem"
@@ -33,14 +36,16 @@ import (
 	"go.viam.com/utils/trace"
 
 	"github.com/corentings/chess/v2"
-	"github.com/corentings/chess/v2/uci"
 
 	"github.com/erh/vmodutils/touch"
 )
 
 var ChessModel = family.WithModel("chess")
 
-const safeZ = 200.0
+const (
+	defaultSafeZ  = 200.0
+	builtinEngine = "builtin"
+)
 
 func init() {
 	enableTracing()
@@ -54,27 +59,78 @@ func init() {
 type ChessConfig struct {
 	PieceFinder string `json:"piece-finder"`
 
-	Arm     string
-	Gripper string
+	Arm         string
+	Gripper     string
+	GripperType string `json:"gripper-type"` // xarm (default), standard or suction
 
 	PoseStart string `json:"pose-start"`
 
-	Engine       string
-	EngineMillis int `json:"engine-millis"`
+	Engine        string
+	EngineMillis  int      `json:"engine-millis"`
+	EngineDepth   int      `json:"engine-depth"`   // optional cap on search depth
+	EngineNodes   int      `json:"engine-nodes"`   // optional cap on nodes searched
+	Skill         *float64 `json:"skill"`          // 0-100
+	FallbackDepth int      `json:"fallback-depth"` // for the built in engine
+
+	Book      string // polyglot .bin
+	BookDepth int    `json:"book-depth"` // full moves to play from the book
+
+	RobotColor string `json:"robot-color"` // the side the robot plays in play mode
+
+	Exhibition *ExhibitionConfig `json:"exhibition"` // the robot against itself
+
+	TimeControl *TimeControl `json:"time-control"` // no clock without it
+
+	EvalMillis int `json:"eval-millis"` // engine time to judge each human move
+
+	Recover string `json:"recover"` // finish or rollback a robot move that got cut off
+
+	GraspWidths    map[string]float64 `json:"grasp-widths"`    // gripper position holding each piece, by letter: p n b r q k
+	GraspTolerance float64            `json:"grasp-tolerance"` // how far off grasp-widths is still a good grip
+	VerifyGrasp    bool               `json:"verify-grasp"`    // look at the board after lifting to check the square is empty
+
+	GraspProfiles map[string]GraspProfile `json:"grasp-profiles"` // how to pick up each piece, by letter: p n b r q k
+
+	VerifyPlace bool `json:"verify-place"` // look at the board after putting a piece down
+
+	Orientation *OrientationConfig `json:"orientation"` // how the gripper leans to reach the far side of the table
+
+	Motion *MotionConfig `json:"motion"` // heights, speeds and constraints for the arm
+}
+
+// player is the robot's engine settings.
+func (cfg *ChessConfig) player() *PlayerConfig {
+	return &PlayerConfig{
+		Engine:        cfg.Engine,
+		EngineMillis:  cfg.EngineMillis,
+		EngineDepth:   cfg.EngineDepth,
+		EngineNodes:   cfg.EngineNodes,
+		Skill:         cfg.Skill,
+		FallbackDepth: cfg.FallbackDepth,
+		Book:          cfg.Book,
+		BookDepth:     cfg.BookDepth,
+	}
 }
 
-func (cfg *ChessConfig) engine() string {
-	if cfg.Engine == "" {
-		return "stockfish"
+func (cfg *ChessConfig) evalMillis() int {
+	if cfg.EvalMillis <= 0 {
+		return defaultEvalMillis
 	}
-	return cfg.Engine
+	return cfg.EvalMillis
 }
 
-func (cfg *ChessConfig) engineMillis() int {
-	if cfg.EngineMillis <= 0 {
-		return 10
+func (cfg *ChessConfig) recover() string {
+	if cfg.Recover == "" {
+		return recoverFinish
 	}
-	return cfg.EngineMillis
+	return cfg.Recover
+}
+
+func (cfg *ChessConfig) robotColor() string {
+	if cfg.RobotColor == "" {
+		return "black"
+	}
+	return cfg.RobotColor
 }
 
 func (cfg *ChessConfig) Validate(path string) ([]string, []string, error) {
@@ -90,6 +146,49 @@ func (cfg *ChessConfig) Validate(path string) ([]string, []string, error) {
 	if cfg.PoseStart == "" {
 		return nil, nil, fmt.Errorf("need a pose-start")
 	}
+	if _, err := newGripperAdapter(cfg.GripperType, nil, nil); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseColor(cfg.robotColor()); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.player().Validate(path); err != nil {
+		return nil, nil, err
+	}
+	if cfg.Exhibition != nil {
+		if err := cfg.Exhibition.Validate(path + ".exhibition"); err != nil {
+			return nil, nil, err
+		}
+		if white, black := cfg.Exhibition.timeControls(cfg.TimeControl); (white == nil) != (black == nil) {
+			return nil, nil, fmt.Errorf("%s.exhibition: both sides need a time-control, or a top level one", path)
+		}
+	}
+	if cfg.recover() != recoverFinish && cfg.recover() != recoverRollback {
+		return nil, nil, fmt.Errorf("bad recover (%s), need finish or rollback", cfg.Recover)
+	}
+	if cfg.TimeControl != nil {
+		if err := cfg.TimeControl.Validate(path + ".time-control"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Motion != nil {
+		if err := cfg.Motion.Validate(path + ".motion"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.Orientation != nil {
+		if err := cfg.Orientation.Validate(path + ".orientation"); err != nil {
+			return nil, nil, err
+		}
+	}
+	for k, gp := range cfg.GraspProfiles {
+		if !strings.Contains("pnbrqk", k) || len(k) != 1 {
+			return nil, nil, fmt.Errorf("bad grasp-profiles piece (%s), need one of p n b r q k", k)
+		}
+		if err := gp.Validate(path + ".grasp-profiles." + k); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	return []string{cfg.PieceFinder, cfg.Arm, cfg.Gripper, cfg.PoseStart, motion.Named("builtin").String()}, nil, nil
 }
@@ -108,6 +207,7 @@ type viamChessChess struct {
 	pieceFinder vision.Service
 	arm         arm.Arm
 	gripper     gripper.Gripper
+	hand        gripperAdapter // how we drive the gripper, per gripper-type
 
 	poseStart toggleswitch.Switch
 
@@ -115,13 +215,32 @@ type viamChessChess struct {
 	rfs    framesystem.Service
 
 	startPose   *referenceframe.PoseInFrame
-	skillAdjust float64
+	startFile   string
+	startJoints []referenceframe.Input // from centering, instead of pose-start, nil if there aren't any
+	atStart     bool                   // the last move was to the start pose, with the gripper open
+
+	joints *jointCache // nil without motion cache-joints
+
+	player *enginePlayer // the robot's engine
+	rng    *rand.Rand
+
+	board *boardPose // nil if the piece finder can't give us one
+
+	calibrationFile string
+	calibration     atomic.Pointer[calibration] // vision to arm correction, nil if not calibrated
 
-	engine *uci.Engine
+	games *gameStore
 
-	fenFile string
+	alertLock sync.Mutex
+	alert     string // for the operator
 
 	doCommandLock sync.Mutex
+
+	playLock   sync.Mutex
+	play       *playSession
+	exhibition *exhibitionSession
+
+	clock *gameClock // nil without a time-control
 }
 
 func newViamChessChess(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (resource.Resource, error) {
@@ -141,12 +260,16 @@ func NewChess(ctx context.Context, deps resource.Dependencies, name resource.Nam
 	cancelCtx, cancelFunc := context.WithCancel(context.Background())
 
 	s := &viamChessChess{
-		name:        name,
-		logger:      logger,
-		conf:        conf,
-		cancelCtx:   cancelCtx,
-		cancelFunc:  cancelFunc,
-		skillAdjust: 50,
+		name:       name,
+		logger:     logger,
+		conf:       conf,
+		cancelCtx:  cancelCtx,
+		cancelFunc: cancelFunc,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if conf.Motion != nil && conf.Motion.CacheJoints {
+		s.joints = &jointCache{}
 	}
 
 	s.pieceFinder, err = vision.FromProvider(deps, conf.PieceFinder)
@@ -164,6 +287,11 @@ func NewChess(ctx context.Context, deps resource.Dependencies, name resource.Nam
 		return nil, err
 	}
 
+	s.hand, err = newGripperAdapter(conf.GripperType, s.arm, s.gripper)
+	if err != nil {
+		return nil, err
+	}
+
 	s.poseStart, err = toggleswitch.FromProvider(deps, conf.PoseStart)
 	if err != nil {
 		return nil, err
@@ -179,22 +307,48 @@ func NewChess(ctx context.Context, deps resource.Dependencies, name resource.Nam
 		logger.Errorf("can't find framesystem: %v", err)
 	}
 
+	s.startFile = filepath.Join(os.Getenv("VIAM_MODULE_DATA"), "start_pose.json")
+	ss, err := readSavedStart(s.startFile)
+	if err != nil {
+		return nil, err
+	}
+	if ss != nil {
+		s.startJoints = ss.Joints
+	}
+
 	err = s.goToStart(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	s.fenFile = os.Getenv("VIAM_MODULE_DATA") + "state.json"
-	s.logger.Infof("fenFile: %v", s.fenFile)
-	s.engine, err = uci.New(conf.engine())
+	s.games, err = openGameStore(ctx, os.Getenv("VIAM_MODULE_DATA"), logger)
 	if err != nil {
 		return nil, err
 	}
+	s.logger.Infof("game %s: %v", s.games.currentGame(), s.games.stateFile())
 
-	err = s.engine.Run(uci.CmdUCI, uci.CmdIsReady, uci.CmdUCINewGame) // TODO: not sure this is correct
+	s.calibrationFile = filepath.Join(os.Getenv("VIAM_MODULE_DATA"), "calibration.json")
+	c, err := readCalibration(s.calibrationFile)
 	if err != nil {
 		return nil, err
 	}
+	s.calibration.Store(c)
+
+	j, err := readJournal(s.games.journalFile())
+	if err != nil {
+		return nil, err
+	}
+	if j != nil {
+		s.logger.Warnf("%s was cut off after %d of %d steps, will %s it before the next move", j.Move, j.Done, len(j.Steps), conf.recover())
+	}
+	s.player, err = newEnginePlayer(conf.player(), logger, s.rng)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.TimeControl != nil || conf.Exhibition.timed() {
+		s.clock = newGameClock(conf.TimeControl, time.Now)
+	}
 
 	return s, nil
 }
@@ -211,18 +365,58 @@ type MoveCmd struct {
 }
 
 type cmdStruct struct {
-	Move   MoveCmd
-	Go     int
-	Reset  bool
-	Wipe   bool
-	Center bool
-	Skill  float64
+	Move       MoveCmd
+	Go         int
+	Reset      bool
+	Wipe       bool
+	Center     bool
+	Save       string // with center, where to keep the new start pose: switch or data
+	Skill      *float64
+	Play       *PlayCmd
+	Exhibition *ExhibitionCmd
+	Clock      *ClockCmd
+	Analyze    *AnalyzeCmd
+	Recover    string
+	Games      *GamesCmd
+	Calibrate  *CalibrateCmd
 }
 
 func (s *viamChessChess) DoCommand(ctx context.Context, cmdMap map[string]interface{}) (map[string]interface{}, error) {
 	ctx, span := trace.StartSpan(ctx, "chess::DoCommand")
 	defer span.End()
 
+	var cmd cmdStruct
+	err := mapstructure.Decode(cmdMap, &cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// these don't wait for, or move, the arm
+	if cmdMap["status"] == true {
+		return s.status(), nil
+	}
+	if cmd.Play != nil {
+		return s.doPlay(*cmd.Play)
+	}
+	if cmd.Exhibition != nil {
+		return s.doExhibition(*cmd.Exhibition)
+	}
+	if cmd.Games != nil {
+		return s.doGames(ctx, *cmd.Games)
+	}
+	if cmd.Calibrate != nil {
+		return s.doCalibrate(ctx, *cmd.Calibrate)
+	}
+	if cmd.Clock != nil {
+		if !s.clock.timed() {
+			return nil, fmt.Errorf("no time-control configured")
+		}
+		if cmd.Clock.Reset {
+			s.clock.reset()
+		}
+		return s.clock.toMap(), nil
+	}
+
 	s.doCommandLock.Lock()
 	defer s.doCommandLock.Unlock()
 
@@ -232,15 +426,16 @@ func (s *viamChessChess) DoCommand(ctx context.Context, cmdMap map[string]interf
 			s.logger.Warnf("can't go home: %v", err)
 		}
 	}()
-	var cmd cmdStruct
-	err := mapstructure.Decode(cmdMap, &cmd)
-	if err != nil {
-		return nil, err
-	}
 
 	if cmd.Move.To != "" && cmd.Move.From != "" {
 		s.logger.Infof("move %v to %v", cmd.Move.From, cmd.Move.To)
 
+		game, err := s.getGame(ctx)
+		if err != nil {
+			return nil, err
+		}
+		around := graveyardOnly(game.graveyard)
+
 		for x := range cmd.Move.N {
 			err := s.goToStart(ctx)
 			if err != nil {
@@ -251,12 +446,12 @@ func (s *viamChessChess) DoCommand(ctx context.Context, cmdMap map[string]interf
 			if x%2 == 1 {
 				to, from = from, to
 			}
-			all, err := s.pieceFinder.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
+			all, err := s.capture(ctx)
 			if err != nil {
 				return nil, err
 			}
 
-			err = s.movePiece(ctx, all, nil, from, to, nil)
+			err = s.movePiece(ctx, all, around, from, to)
 			if err != nil {
 				return nil, err
 			}
@@ -267,16 +462,35 @@ func (s *viamChessChess) DoCommand(ctx context.Context, cmdMap map[string]interf
 
 	if cmd.Go > 0 {
 		var m *chess.Move
+		var human *moveRecord
 		for n := range cmd.Go {
-			m, err = s.makeAMove(ctx, n == 0)
+			var h *moveRecord
+			m, h, err = s.makeAMove(ctx, s.player, n == 0)
 			if err != nil {
 				return nil, err
 			}
+			if h != nil {
+				human = h
+			}
+		}
+		res := map[string]interface{}{"move": m.String()}
+		if human != nil {
+			res["human"] = human.toMap()
 		}
-		return map[string]interface{}{"move": m.String()}, nil
+		return res, nil
+	}
+
+	if cmd.Recover != "" {
+		_, res, err := s.recoverMove(ctx, cmd.Recover)
+		return res, err
+	}
+
+	if cmd.Analyze != nil {
+		return s.analyze(ctx, *cmd.Analyze)
 	}
 
 	if cmd.Reset {
+		s.clock.reset()
 		return nil, s.resetBoard(ctx)
 	}
 
@@ -285,29 +499,115 @@ func (s *viamChessChess) DoCommand(ctx context.Context, cmdMap map[string]interf
 	}
 
 	if cmd.Center {
-		return nil, s.centerCamera(ctx)
+		return s.centerCamera(ctx, cmd.Save)
 	}
 
-	if cmd.Skill > 0 {
-		s.skillAdjust = cmd.Skill
-		return nil, nil
+	if cmd.Skill != nil {
+		err := s.player.setStrength(*cmd.Skill)
+		if err != nil {
+			return nil, err
+		}
+		return s.player.strength.toMap(s.player.strengthOptions), nil
 	}
 
 	return nil, fmt.Errorf("bad cmd %v", cmdMap)
 }
 
+func (s *viamChessChess) status() map[string]interface{} {
+	m := map[string]interface{}{
+		"engine": s.player.status(),
+	}
+
+	s.playLock.Lock()
+	if s.play != nil {
+		m["play"] = s.play.toMap()
+	}
+	if s.exhibition != nil {
+		m["exhibition"] = s.exhibition.toMap()
+	}
+	s.playLock.Unlock()
+
+	if s.clock.timed() {
+		m["clock"] = s.clock.toMap()
+	}
+
+	m["game"] = s.games.currentGame()
+	m["calibration"] = s.calibration.Load().toMap()
+	if s.joints != nil {
+		m["cached_positions"] = s.joints.size()
+	}
+	if alert := s.getAlert(); alert != "" {
+		m["alert"] = alert
+	}
+
+	j, err := readJournal(s.games.journalFile())
+	if err != nil {
+		m["journal"] = err.Error()
+	} else if j != nil {
+		m["journal"] = j.toMap()
+	}
+
+	return m
+}
+
 func (s *viamChessChess) Close(ctx context.Context) error {
 	var err error
 
+	s.stopPlay()
+	s.stopExhibition()
 	s.cancelFunc()
 
-	if s.engine != nil {
-		err = multierr.Combine(err, s.engine.Close())
+	if s.player != nil {
+		err = multierr.Combine(err, s.player.Close())
 	}
 
+	s.joints.clear() // a reconfigure gets a new one, this one shouldn't outlive the config it was planned with
+
 	return err
 }
 
+// capture looks at the board, and refreshes the board model while we're at it.
+func (s *viamChessChess) capture(ctx context.Context) (viscapture.VisCapture, error) {
+	all, err := s.pieceFinder.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
+	if err != nil {
+		return all, err
+	}
+
+	res, err := s.pieceFinder.DoCommand(ctx, map[string]interface{}{"board": true})
+	if err != nil {
+		s.logger.Debugf("no board pose from piece finder, using per-capture centers: %v", err)
+		return all, nil
+	}
+
+	b, err := boardPoseFromMap(res)
+	if err != nil {
+		s.logger.Warnf("bad board pose from piece finder: %v", err)
+		return all, nil
+	}
+	s.board = b
+
+	return all, nil
+}
+
+// worldState is what the arm has to avoid, everything but the squares in skip.
+// Nil when we don't know where the board is, motion then relies on the safe height.
+// graveyardOnly is a state for moving pieces outside of a game: it knows what's in the graveyard, to plan
+// around it and to know where the next slot is, but not what's on the board.
+func graveyardOnly(graveyard []int) *state {
+	return &state{graveyard: graveyard}
+}
+
+func (s *viamChessChess) worldState(data viscapture.VisCapture, theState *state, skip ...string) (*referenceframe.WorldState, error) {
+	if s.board == nil {
+		return nil, nil
+	}
+	var graveyard []int
+	if theState != nil {
+		graveyard = theState.graveyard
+	}
+	return s.board.worldState(data.Objects, graveyard, skip...)
+}
+
 func (s *viamChessChess) findObject(data viscapture.VisCapture, pos string) *viz.Object {
 	for _, o := range data.Objects {
 		if strings.HasPrefix(o.Geometry.Label(), pos) {
@@ -327,6 +627,11 @@ func (s *viamChessChess) findDetection(data viscapture.VisCapture, pos string) o
 }
 
 func (s *viamChessChess) graveyardPosition(data viscapture.VisCapture, pos int) (r3.Vector, error) {
+	if s.board != nil {
+		x, y := s.board.graveyardSpot(pos)
+		return s.board.point(x, y, 60), nil
+	}
+
 	f := 8 - (pos % 8)
 	ex := 1 + (pos / 8)
 
@@ -341,7 +646,16 @@ func (s *viamChessChess) graveyardPosition(data viscapture.VisCapture, pos int)
 
 }
 
+// getCenterFor is where the arm should go for pos, vision's idea of it corrected by the calibration.
 func (s *viamChessChess) getCenterFor(data viscapture.VisCapture, pos string, theState *state) (r3.Vector, error) {
+	v, err := s.visionCenterFor(data, pos, theState)
+	if err != nil || s == nil {
+		return v, err
+	}
+	return s.calibration.Load().apply(v), nil
+}
+
+func (s *viamChessChess) visionCenterFor(data viscapture.VisCapture, pos string, theState *state) (r3.Vector, error) {
 	if pos == "-" {
 		if s == nil {
 			return r3.Vector{400, -400, 200}, nil
@@ -367,6 +681,14 @@ func (s *viamChessChess) getCenterFor(data viscapture.VisCapture, pos string, th
 	md := o.MetaData()
 	center := md.Center()
 
+	if s != nil && s.board != nil {
+		bc, err := s.board.squareCenter(pos)
+		if err != nil {
+			return r3.Vector{}, err
+		}
+		center = bc
+	}
+
 	if strings.HasSuffix(o.Geometry.Label(), "-0") {
 		return center, nil
 	}
@@ -379,7 +701,8 @@ func (s *viamChessChess) getCenterFor(data viscapture.VisCapture, pos string, th
 	}, nil
 }
 
-func (s *viamChessChess) movePiece(ctx context.Context, data viscapture.VisCapture, theState *state, from, to string, m *chess.Move) error {
+// movePiece moves whatever is at from to to, putting anything already at to in the graveyard first.
+func (s *viamChessChess) movePiece(ctx context.Context, data viscapture.VisCapture, theState *state, from, to string) error {
 	ctx, span := trace.StartSpan(ctx, "movePiece")
 	defer span.End()
 
@@ -395,19 +718,40 @@ func (s *viamChessChess) movePiece(ctx context.Context, data viscapture.VisCaptu
 			what := "?"
 
 			s.logger.Infof("position %s already has a piece (%s) (%s), will move", to, what, o.Geometry.Label())
-			err := s.movePiece(ctx, data, theState, to, "-", nil)
+			pc := pieceAt(theState, to)
+			err := s.movePiece(ctx, data, theState, to, "-")
 			if err != nil {
 				return fmt.Errorf("can't move piece out of the way: %w", err)
 			}
 
 			if theState != nil {
-				pc := theState.game.Position().Board().Piece(m.S2())
 				theState.graveyard = append(theState.graveyard, int(pc))
 			}
 
 		}
 	}
 
+	return s.transfer(ctx, data, theState, from, to, chess.NoPiece, nil)
+}
+
+// transfer picks up whatever is at from and puts it down at to, without looking at what's there.
+// pc is what's being moved, NoPiece to go by what the game has at from. With verify-place and expect,
+// what the board should look like after, it checks the piece landed.
+func (s *viamChessChess) transfer(ctx context.Context, data viscapture.VisCapture, theState *state, from, to string, pc chess.Piece, expect map[chess.Square]int) error {
+	ctx, span := trace.StartSpan(ctx, "transfer")
+	defer span.End()
+
+	if pc == chess.NoPiece {
+		pc = pieceAt(theState, from)
+	}
+	heightBefore := s.heightAt(data, from)
+	gp := s.conf.graspProfile(pc)
+
+	ws, err := s.worldState(data, theState, from, to)
+	if err != nil {
+		return err
+	}
+
 	useZ := 100.0
 
 	{
@@ -415,47 +759,8 @@ func (s *viamChessChess) movePiece(ctx context.Context, data viscapture.VisCaptu
 		if err != nil {
 			return err
 		}
-		useZ = center.Z
-
-		err = s.setupGripper(ctx)
-		if err != nil {
-			return err
-		}
 
-		err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, safeZ})
-		if err != nil {
-			return err
-		}
-
-		for {
-			err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, useZ})
-			if err != nil {
-				return err
-			}
-
-			got, err := s.myGrab(ctx)
-			if err != nil {
-				return err
-			}
-			if got {
-				break
-			}
-
-			useZ -= 10
-			if useZ < 12 { // todo: magic number
-				return fmt.Errorf("couldn't grab, and scared to go lower")
-			}
-
-			s.logger.Warnf("didn't grab, going to try a little more")
-
-			err = s.setupGripper(ctx)
-			if err != nil {
-				return err
-			}
-			time.Sleep(250 * time.Millisecond)
-		}
-
-		err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, safeZ})
+		useZ, err = s.grasp(ctx, pc, from, center, ws)
 		if err != nil {
 			return err
 		}
@@ -467,290 +772,210 @@ func (s *viamChessChess) movePiece(ctx context.Context, data viscapture.VisCaptu
 			return err
 		}
 
-		err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, safeZ})
-		if err != nil {
-			return err
-		}
-
-		err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, useZ})
+		err = s.travelDown(ctx, r3.Vector{X: center.X, Y: center.Y, Z: useZ}, ws)
 		if err != nil {
 			return err
 		}
 
-		err = s.setupGripper(ctx)
+		err = s.hand.prepare(ctx, gp.OpenWidth)
 		if err != nil {
 			return err
 		}
 
-		err = s.moveGripper(ctx, r3.Vector{center.X, center.Y, safeZ})
+		err = s.goUp(ctx, r3.Vector{X: center.X, Y: center.Y, Z: useZ}, ws)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return s.verifyPlace(ctx, theState, to, pc, expect, heightBefore, true)
 }
 
 func (s *viamChessChess) goToStart(ctx context.Context) error {
 	ctx, span := trace.StartSpan(ctx, "goToStart")
 	defer span.End()
 
-	err := s.poseStart.SetPosition(ctx, 2, nil)
-	if err != nil {
-		return err
+	if s.alreadyAtStart(ctx) {
+		return nil
+	}
+	return s.goHome(ctx, true)
+}
+
+// goToStartHolding goes to the start pose without opening the gripper, to look at the board with a piece in hand.
+func (s *viamChessChess) goToStartHolding(ctx context.Context) error {
+	ctx, span := trace.StartSpan(ctx, "goToStartHolding")
+	defer span.End()
+
+	return s.goHome(ctx, false)
+}
+
+func (s *viamChessChess) goHome(ctx context.Context, open bool) error {
+	var err error
+	if s.startJoints != nil {
+		err = s.arm.MoveThroughJointPositions(ctx, [][]referenceframe.Input{s.startJoints}, s.conf.Motion.moveOptions(0), nil)
+	} else {
+		err = s.poseStart.SetPosition(ctx, 2, nil)
 	}
-	err = s.gripper.Open(ctx, nil)
 	if err != nil {
 		return err
 	}
+	if open {
+		err = s.hand.release(ctx)
+		if err != nil {
+			return err
+		}
+	}
 
 	time.Sleep(time.Millisecond * 250)
 
+	old := s.startPose
 	s.startPose, err = s.rfs.GetPose(ctx, s.conf.Gripper, "world", nil, nil)
 	if err != nil {
 		return err
 	}
+	if old != nil && startThetaChanged(old, s.startPose) {
+		s.joints.clear() // every move is turned to match the start pose, so the cached joints are off
+	}
 
+	s.atStart = open
 	return nil
 }
 
-func (s *viamChessChess) setupGripper(ctx context.Context) error {
-	ctx, span := trace.StartSpan(ctx, "setupGripper")
-	defer span.End()
-
-	_, err := s.arm.DoCommand(ctx, map[string]interface{}{"move_gripper": 450.0})
-	return err
+// moveGripper moves the gripper to p pointing down, leaning per the orientation config
+// if it has to. Each tilt is tried until one can be planned, then the arm goes.
+func (s *viamChessChess) moveGripper(ctx context.Context, p r3.Vector, ws *referenceframe.WorldState) error {
+	return s.moveTo(ctx, p, ws, false, 0)
 }
 
-func (s *viamChessChess) moveGripper(ctx context.Context, p r3.Vector) error {
+// moveTo is moveGripper, and with straight it keeps to a line, per the motion config's line-tolerance.
+// speed is joint degs/sec, 0 for the motion config's.
+func (s *viamChessChess) moveTo(ctx context.Context, p r3.Vector, ws *referenceframe.WorldState, straight bool, speed float64) error {
 	ctx, span := trace.StartSpan(ctx, "moveGripper")
 	defer span.End()
 
-	orientation := &spatialmath.OrientationVectorDegrees{
-		OZ:    -1,
-		Theta: s.startPose.Pose().Orientation().OrientationVectorDegrees().Theta,
-	}
+	s.atStart = false
 
-	if p.X > 300 {
-		orientation.OX = (p.X - 300) / 1000
+	var constraints *motionplan.Constraints
+	if straight {
+		constraints = s.conf.Motion.linear()
+	} else if j, ok := s.joints.get(p); ok {
+		return s.execute(ctx, [][]referenceframe.Input{j}, speed)
 	}
 
-	if p.Y < -300 {
-		orientation.OY = (p.Y + 300) / 300
-		orientation.OX += .2
-	}
+	theta := s.startPose.Pose().Orientation().OrientationVectorDegrees().Theta
 
-	myPose := spatialmath.NewPose(p, orientation)
-	_, err := s.motion.Move(ctx, motion.MoveReq{
-		ComponentName: s.conf.Gripper,
-		Destination:   referenceframe.NewPoseInFrame("world", myPose),
-	})
-	if err != nil {
-		return fmt.Errorf("can't move to %v: %w", myPose, err)
+	var errs error
+	for _, t := range s.conf.Orientation.tilts(p) {
+		myPose := spatialmath.NewPose(p, &spatialmath.OrientationVectorDegrees{OX: t[0], OY: t[1], OZ: -1, Theta: theta})
+		joints, err := s.plan(ctx, motion.MoveReq{
+			ComponentName: s.conf.Gripper,
+			Destination:   referenceframe.NewPoseInFrame("world", myPose),
+			WorldState:    ws,
+			Constraints:   constraints,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Debugf("can't plan to %v with tilt %v: %v", p, t, err)
+			errs = multierr.Append(errs, err)
+			continue
+		}
+
+		// it planned, so whatever goes wrong now isn't for another tilt to fix
+		err = s.execute(ctx, joints, speed)
+		if err != nil {
+			return fmt.Errorf("can't move to %v: %w", myPose, err)
+		}
+		if !straight {
+			s.remember(ctx, p)
+		}
+		return nil
 	}
-	return nil
+	return fmt.Errorf("can't move to %v with any tilt: %w", p, errs)
 }
 
 type state struct {
 	game      *chess.Game
 	graveyard []int
+	moves     []moveRecord
 }
 
 type savedState struct {
+	Version   int    `json:"version"`
 	FEN       string `json:"fen"`
 	Graveyard []int  `json:"graveyard"`
-}
 
-func (s *viamChessChess) getGame(ctx context.Context) (*state, error) {
-	return readState(ctx, s.fenFile)
+	Moves []moveRecord `json:"moves,omitempty"`
 }
 
-func readState(ctx context.Context, fn string) (*state, error) {
-	ctx, span := trace.StartSpan(ctx, "readState")
-	defer span.End()
-
-	data, err := os.ReadFile(fn)
-	if os.IsNotExist(err) {
-		return &state{chess.NewGame(), []int{}}, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("error reading fen (%s) %T", fn, err)
-	}
-
-	ss := savedState{}
-	err = json.Unmarshal(data, &ss)
-	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal json")
-	}
-
-	f, err := chess.FEN(ss.FEN)
-	if err != nil {
-		return nil, fmt.Errorf("invalid fen from (%s) (%s) %w", fn, data, err)
-	}
-	return &state{chess.NewGame(f), ss.Graveyard}, nil
+func (s *viamChessChess) getGame(ctx context.Context) (*state, error) {
+	return readState(ctx, s.games.stateFile())
 }
 
 func (s *viamChessChess) saveGame(ctx context.Context, theState *state) error {
-	ctx, span := trace.StartSpan(ctx, "saveGame")
+	_, span := trace.StartSpan(ctx, "saveGame")
 	defer span.End()
 
-	ss := savedState{
-		FEN:       theState.game.FEN(),
-		Graveyard: theState.graveyard,
-	}
-	b, err := json.MarshalIndent(&ss, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(s.fenFile, b, 0666)
+	return writeState(s.games.stateFile(), theState)
 }
 
-func (s *viamChessChess) pickMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
-	ctx, span := trace.StartSpan(ctx, "pickMove")
+// makeAMove plays the robot's move. With doSanityCheck, it first looks for a move the human made, and returns it too.
+func (s *viamChessChess) makeAMove(ctx context.Context, player *enginePlayer, doSanityCheck bool) (*chess.Move, *moveRecord, error) {
+	ctx, span := trace.StartSpan(ctx, "makeAMove")
 	defer span.End()
 
-	if s.engine == nil {
-		moves := game.ValidMoves()
-		if len(moves) == 0 {
-			return nil, fmt.Errorf("no valid moves")
-		}
-		return &moves[0], nil
-	}
-
-	multiplier := 1.0
-	if s.skillAdjust < 50 {
-		multiplier = float64(s.skillAdjust) / 50.0
-		s.logger.Infof("multiplier: %v", multiplier)
-	} else if s.skillAdjust > 50 {
-		multiplier = float64(s.skillAdjust-50) * 2
-		s.logger.Infof("multiplier: %v", multiplier)
-	}
-
-	cmdPos := uci.CmdPosition{Position: game.Position()}
-	cmdGo := uci.CmdGo{MoveTime: time.Millisecond * time.Duration(float64(s.conf.engineMillis())*multiplier)}
-	err := s.engine.Run(cmdPos, cmdGo)
+	err := s.goToStart(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("can't go home: %v", err)
 	}
 
-	return s.engine.SearchResults().BestMove, nil
-
-}
-
-func (s *viamChessChess) makeAMove(ctx context.Context, doSanityCheck bool) (*chess.Move, error) {
-	ctx, span := trace.StartSpan(ctx, "makeAMove")
-	defer span.End()
-
-	err := s.goToStart(ctx)
+	// a move that got cut off comes first, if it gets finished that's our move
+	m, _, err := s.recoverMove(ctx, s.conf.recover())
 	if err != nil {
-		return nil, fmt.Errorf("can't go home: %v", err)
+		return nil, nil, fmt.Errorf("can't recover the last move: %w", err)
+	}
+	if m != nil {
+		return m, nil, s.goToStart(ctx)
 	}
 
 	theState, err := s.getGame(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	all, err := s.pieceFinder.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
+	all, err := s.capture(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	var human *moveRecord
 	if doSanityCheck {
-		err = s.checkPositionForMoves(ctx, all)
+		human, err = s.checkPositionForMoves(ctx, all)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-	}
-
-	m, err := s.pickMove(ctx, theState.game)
-	if err != nil {
-		return nil, err
-	}
-
-	if m.HasTag(chess.KingSideCastle) || m.HasTag(chess.QueenSideCastle) {
-		var f, t string
-		switch m.S1().String() {
-		case "e1":
-			switch m.S2().String() {
-			case "g1":
-				f = "h1"
-				t = "f1"
-			case "a1":
-				f = "a1"
-				t = "c1"
-			default:
-				return nil, fmt.Errorf("bad castle? %v", m)
-			}
-		case "e8":
-			switch m.S2().String() {
-			case "g8":
-				f = "h8"
-				t = "f8"
-			case "a8":
-				f = "a8"
-				t = "c8"
-			default:
-				return nil, fmt.Errorf("bad castle? %v", m)
+		if human != nil { // it's been saved with the human's move in it
+			theState, err = s.getGame(ctx)
+			if err != nil {
+				return nil, nil, err
 			}
-		default:
-			return nil, fmt.Errorf("bad castle? %v", m)
 		}
-
-		err = s.movePiece(ctx, all, nil, f, t, nil)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if m.HasTag(chess.EnPassant) {
-		return nil, fmt.Errorf("can't handle enpassant")
 	}
 
-	err = s.movePiece(ctx, all, theState, m.S1().String(), m.S2().String(), m)
+	turn := theState.game.Position().Turn()
+	m, err = player.pickMove(ctx, theState.game, s.clock)
 	if err != nil {
-		return nil, err
-	}
-
-	err = theState.game.Move(m, nil)
-	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	err = s.saveGame(ctx, theState)
-	if err != nil {
-		return nil, err
-	}
-
-	return m, nil
-}
-
-func (s *viamChessChess) myGrab(ctx context.Context) (bool, error) {
-	got, err := s.gripper.Grab(ctx, nil)
-	if err != nil {
-		return false, err
-	}
-
-	time.Sleep(300 * time.Millisecond)
-
-	res, err := s.arm.DoCommand(ctx, map[string]interface{}{"get_gripper": true})
+	err = s.playMove(ctx, all, theState, m)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
+	s.clock.moved(turn)
 
-	p, ok := res["gripper_position"].(float64)
-	if !ok {
-		return false, fmt.Errorf("Why is get_gripper weird %v", res)
-	}
-
-	s.logger.Debugf("gripper res: %v", res)
-
-	if p < 20 && got {
-		s.logger.Warnf("grab said we got, but i think no res: %v", res)
-		return false, nil
-	}
-
-	return got, nil
+	return m, human, nil
 }
 
 func (s *viamChessChess) resetBoard(ctx context.Context) error {
@@ -760,6 +985,7 @@ func (s *viamChessChess) resetBoard(ctx context.Context) error {
 	}
 
 	theState := &resetState{theMainState.game.Position().Board(), theMainState.graveyard}
+	around := graveyardOnly(theState.graveyard) // same slots, emptied as pieces come back
 
 	for {
 		from, to, err := nextResetMove(theState)
@@ -775,12 +1001,12 @@ func (s *viamChessChess) resetBoard(ctx context.Context) error {
 			return err
 		}
 
-		all, err := s.pieceFinder.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
+		all, err := s.capture(ctx)
 		if err != nil {
 			return err
 		}
 
-		err = s.movePiece(ctx, all, nil, squareToString(from), squareToString(to), nil)
+		err = s.movePiece(ctx, all, around, squareToString(from), squareToString(to))
 		if err != nil {
 			return err
 		}
@@ -795,165 +1021,41 @@ func (s *viamChessChess) resetBoard(ctx context.Context) error {
 }
 
 func (s *viamChessChess) wipe(ctx context.Context) error {
-	return os.Remove(s.fenFile)
+	err := removeJournal(s.games.journalFile())
+	if err != nil {
+		return err
+	}
+	return os.Remove(s.games.stateFile())
 }
 
-func (s *viamChessChess) checkPositionForMoves(ctx context.Context, all viscapture.VisCapture) error {
+// checkPositionForMoves looks for a move the human made, nil if there isn't one.
+func (s *viamChessChess) checkPositionForMoves(ctx context.Context, all viscapture.VisCapture) (*moveRecord, error) {
 	ctx, span := trace.StartSpan(ctx, "checkPositionForMoves")
 	defer span.End()
 
 	theState, err := s.getGame(ctx)
 	if err != nil {
-		return err
-	}
-
-	differnces := []chess.Square{}
-	from := chess.NoSquare
-	to := chess.NoSquare
-
-	for sq := chess.A1; sq <= chess.H8; sq++ {
-		x := squareToString(sq)
-
-		fromState := theState.game.Position().Board().Piece(sq)
-		o := s.findObject(all, x)
-		oc := int(o.Geometry.Label()[3] - '0')
-
-		if int(fromState.Color()) != oc {
-			s.logger.Infof("differnent %s fromState: %v o: %v oc: %v", x, fromState, o.Geometry.Label(), oc)
-			differnces = append(differnces, sq)
-			if oc == 0 {
-				from = sq
-			} else if oc > 0 {
-				to = sq
-			}
-		}
-
-	}
-
-	if len(differnces) == 0 {
-		return nil
+		return nil, err
 	}
 
-	if len(differnces) == 4 {
-		// is this a castle??
-		if squaresSame(differnces, []chess.Square{chess.E1, chess.F1, chess.G1, chess.H1}) {
-			// white king castle
-			from = chess.E1
-			to = chess.G1
-			differnces = nil
-		} else if squaresSame(differnces, []chess.Square{chess.E1, chess.A1, chess.C1, chess.D1}) {
-			// white queen castle
-			from = chess.E1
-			to = chess.C1
-			differnces = nil
-		} else if squaresSame(differnces, []chess.Square{chess.E8, chess.F8, chess.G8, chess.H8}) {
-			// black king castle
-			from = chess.E8
-			to = chess.G8
-			differnces = nil
-		} else if squaresSame(differnces, []chess.Square{chess.E8, chess.A8, chess.C8, chess.D8}) {
-			// black queen castle
-			from = chess.E8
-			to = chess.C8
-			differnces = nil
-		}
-	}
-
-	if len(differnces) != 2 && len(differnces) != 0 {
-		return fmt.Errorf("bad number of differnces (%d) : %v", len(differnces), differnces)
-	}
-
-	moves := theState.game.ValidMoves()
-	for _, m := range moves {
-		if m.S1() == from && m.S2() == to {
-			s.logger.Infof("found it: %v", m.String())
-			err = theState.game.Move(&m, nil)
-			if err != nil {
-				return err
-			}
-
-			err = s.saveGame(ctx, theState)
-			if err != nil {
-				return err
-			}
-
-			return nil
-		}
+	colors, err := s.boardColors(all)
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Errorf("no valid moves from: %v to %v found out of %d", from, to, len(moves))
-}
-
-func (s *viamChessChess) centerCamera(ctx context.Context) error {
-	err := s.goToStart(ctx)
+	m, err := findMoveFromColors(theState.game.Position(), colors)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-
-	//pose := s.startPose.Pose()
-
-	for {
-		time.Sleep(time.Second)
-
-		all, err := s.pieceFinder.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
-		if err != nil {
-			return err
-		}
-
-		sum := r3.Vector{}
-		for _, pos := range []string{"d1", "e1", "d8", "e8"} {
-			v, err := s.getCenterFor(all, pos, nil)
-			if err != nil {
-				return err
-			}
-			sum = sum.Add(v)
-		}
-		sum = sum.Mul(.25)
-		s.logger.Infof("hi: %v", sum)
-
-		return fmt.Errorf("finish me")
-
-		/*
-			if math.Abs(xDelta) < 3 && math.Abs(float64(yDelta)) < 3 {
-				return nil
-			}
-
-			pose = spatialmath.NewPose(
-				pose.Point().Add(r3.Vector{X: xDelta / 3, Y: yDelta / 3}),
-				pose.Orientation(),
-			)
-
-			s.logger.Infof("updated pose: %v", pose)
-
-			_, err = s.motion.Move(ctx, motion.MoveReq{
-				ComponentName: s.conf.Gripper,
-				Destination:   referenceframe.NewPoseInFrame("world", pose),
-			})
-			if err != nil {
-				return fmt.Errorf("can't move to %v: %w", pose, err)
-			}
-		*/
-
+	if m == nil {
+		return nil, nil
 	}
-}
 
-func squaresSame(a, b []chess.Square) bool {
-	if len(a) != len(b) {
-		return false
+	s.logger.Infof("found it: %v", m.String())
+	rec, err := s.humanMoved(ctx, theState, m)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check that every element in a exists in b
-	for _, sq := range a {
-		found := false
-		for _, sq2 := range b {
-			if sq == sq2 {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-	return true
+	return rec, s.saveGame(ctx, theState)
 }
@@ -0,0 +1,217 @@
+package viamchess
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+)
+
+// TimeControl is base time per side plus an increment per move, like 5+3 blitz.
+type TimeControl struct {
+	BaseSeconds      float64 `json:"base-seconds"`
+	IncrementSeconds float64 `json:"increment-seconds"`
+}
+
+func (tc *TimeControl) Validate(path string) error {
+	if tc.BaseSeconds <= 0 {
+		return fmt.Errorf("%s: base-seconds has to be more than 0", path)
+	}
+	if tc.IncrementSeconds < 0 {
+		return fmt.Errorf("%s: increment-seconds can't be negative", path)
+	}
+	return nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+type ClockCmd struct {
+	Reset bool
+}
+
+// gameClock is a chess clock. It only runs while a game is being played (play or exhibition mode).
+// A nil clock means no time control, and all the methods are fine to call on it. Each side can have
+// its own time control, and with neither it never runs.
+type gameClock struct {
+	now func() time.Time
+
+	mu              sync.Mutex
+	base, increment map[chess.Color]time.Duration // empty when there's no time control
+	remaining       map[chess.Color]time.Duration
+	running         chess.Color // NoColor when stopped
+	since           time.Time   // when running's clock last started
+	flagged         chess.Color // who ran out of time
+}
+
+func newGameClock(tc *TimeControl, now func() time.Time) *gameClock {
+	c := &gameClock{now: now}
+	c.use(tc, tc)
+	return c
+}
+
+// use gives each side its time control and starts the clock over.
+func (c *gameClock) use(white, black *TimeControl) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = map[chess.Color]time.Duration{}
+	c.increment = map[chess.Color]time.Duration{}
+	for color, tc := range map[chess.Color]*TimeControl{chess.White: white, chess.Black: black} {
+		if tc != nil {
+			c.base[color] = seconds(tc.BaseSeconds)
+			c.increment[color] = seconds(tc.IncrementSeconds)
+		}
+	}
+	c.resetLocked()
+}
+
+// timed says if there's a time control, a clock without one is only there for exhibition mode.
+func (c *gameClock) timed() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.base) > 0
+}
+
+func (c *gameClock) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetLocked()
+}
+
+func (c *gameClock) resetLocked() {
+	c.remaining = map[chess.Color]time.Duration{chess.White: c.base[chess.White], chess.Black: c.base[chess.Black]}
+	c.running = chess.NoColor
+	c.flagged = chess.NoColor
+}
+
+// tick takes the time since the last tick off whoever's clock is running.
+func (c *gameClock) tick() {
+	if c.running == chess.NoColor {
+		return
+	}
+	now := c.now()
+	c.remaining[c.running] -= now.Sub(c.since)
+	c.since = now
+	if c.remaining[c.running] <= 0 {
+		c.remaining[c.running] = 0
+		c.flagged = c.running
+		c.running = chess.NoColor
+	}
+}
+
+// start runs turn's clock.
+func (c *gameClock) start(turn chess.Color) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	if c.flagged != chess.NoColor || len(c.base) == 0 {
+		return
+	}
+	c.running = turn
+	c.since = c.now()
+}
+
+func (c *gameClock) stop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	c.running = chess.NoColor
+}
+
+// moved is pressing the clock after color's move: they get the increment, unless they were already out of time.
+func (c *gameClock) moved(color chess.Color) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running != color {
+		return
+	}
+	c.tick()
+	if c.flagged != chess.NoColor {
+		return
+	}
+	c.remaining[color] += c.increment[color]
+	c.running = color.Other()
+	c.since = c.now()
+}
+
+// over says if someone has lost on time.
+func (c *gameClock) over() (bool, string) {
+	if c == nil {
+		return false, ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	switch c.flagged {
+	case chess.White:
+		return true, "0-1 on time"
+	case chess.Black:
+		return true, "1-0 on time"
+	}
+	return false, ""
+}
+
+// limit gives the engine the clock instead of a fixed move time, while it's running. Outside play and
+// exhibition mode, like a plain go, the engine keeps its move time.
+func (c *gameClock) limit(cmd uci.CmdGo) uci.CmdGo {
+	if c == nil {
+		return cmd
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	if c.running == chess.NoColor {
+		return cmd
+	}
+	cmd.MoveTime = 0
+	cmd.WhiteTime = max(c.remaining[chess.White], time.Millisecond)
+	cmd.BlackTime = max(c.remaining[chess.Black], time.Millisecond)
+	cmd.WhiteIncrement = c.increment[chess.White]
+	cmd.BlackIncrement = c.increment[chess.Black]
+	return cmd
+}
+
+func (c *gameClock) toMap() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	m := map[string]interface{}{
+		"white_ms": c.remaining[chess.White].Milliseconds(),
+		"black_ms": c.remaining[chess.Black].Milliseconds(),
+		"running":  "",
+	}
+	if c.increment[chess.White] == c.increment[chess.Black] {
+		m["increment_ms"] = c.increment[chess.White].Milliseconds()
+	} else {
+		m["white_increment_ms"] = c.increment[chess.White].Milliseconds()
+		m["black_increment_ms"] = c.increment[chess.Black].Milliseconds()
+	}
+	if c.running != chess.NoColor {
+		m["running"] = c.running.Name()
+	}
+	if c.flagged != chess.NoColor {
+		m["flagged"] = c.flagged.Name()
+	}
+	return m
+}
@@ -0,0 +1,115 @@
+package viamchess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+
+	"go.viam.com/test"
+)
+
+type fakeTime struct {
+	t time.Time
+}
+
+func (f *fakeTime) now() time.Time {
+	return f.t
+}
+
+func (f *fakeTime) add(d time.Duration) {
+	f.t = f.t.Add(d)
+}
+
+func TestGameClock(t *testing.T) {
+	ft := &fakeTime{t: time.Unix(1000, 0)}
+	c := newGameClock(&TimeControl{BaseSeconds: 60, IncrementSeconds: 2}, ft.now)
+
+	// not running yet
+	ft.add(time.Minute)
+	over, _ := c.over()
+	test.That(t, over, test.ShouldBeFalse)
+	test.That(t, c.toMap()["white_ms"], test.ShouldEqual, int64(60000))
+
+	c.start(chess.White)
+	ft.add(10 * time.Second)
+	c.moved(chess.White)
+	test.That(t, c.toMap()["white_ms"], test.ShouldEqual, int64(52000))
+	test.That(t, c.toMap()["running"], test.ShouldEqual, "Black")
+
+	// a move out of turn doesn't touch the clock
+	c.moved(chess.White)
+	test.That(t, c.toMap()["running"], test.ShouldEqual, "Black")
+
+	ft.add(20 * time.Second)
+	cmd := c.limit(uci.CmdGo{MoveTime: time.Second})
+	test.That(t, cmd.MoveTime, test.ShouldEqual, time.Duration(0))
+	test.That(t, cmd.WhiteTime, test.ShouldEqual, 52*time.Second)
+	test.That(t, cmd.BlackTime, test.ShouldEqual, 40*time.Second)
+	test.That(t, cmd.BlackIncrement, test.ShouldEqual, 2*time.Second)
+
+	c.stop()
+	ft.add(time.Hour)
+	test.That(t, c.toMap()["black_ms"], test.ShouldEqual, int64(40000))
+
+	// stopped, the engine keeps its move time
+	test.That(t, c.limit(uci.CmdGo{MoveTime: time.Second}), test.ShouldResemble, uci.CmdGo{MoveTime: time.Second})
+
+	c.start(chess.Black)
+	ft.add(41 * time.Second)
+	over, result := c.over()
+	test.That(t, over, test.ShouldBeTrue)
+	test.That(t, result, test.ShouldEqual, "1-0 on time")
+	test.That(t, c.toMap()["black_ms"], test.ShouldEqual, int64(0))
+	test.That(t, c.toMap()["flagged"], test.ShouldEqual, "Black")
+
+	// too late for the increment
+	c.moved(chess.Black)
+	test.That(t, c.toMap()["black_ms"], test.ShouldEqual, int64(0))
+
+	c.reset()
+	over, _ = c.over()
+	test.That(t, over, test.ShouldBeFalse)
+	test.That(t, c.toMap()["black_ms"], test.ShouldEqual, int64(60000))
+}
+
+func TestGameClockSides(t *testing.T) {
+	ft := &fakeTime{t: time.Unix(1000, 0)}
+	c := newGameClock(nil, ft.now)
+	test.That(t, c.timed(), test.ShouldBeFalse)
+
+	// no time control, it never runs
+	c.start(chess.White)
+	test.That(t, c.toMap()["running"], test.ShouldEqual, "")
+	test.That(t, c.limit(uci.CmdGo{MoveTime: time.Second}).MoveTime, test.ShouldEqual, time.Second)
+
+	c.use(&TimeControl{BaseSeconds: 300, IncrementSeconds: 3}, &TimeControl{BaseSeconds: 60})
+	test.That(t, c.timed(), test.ShouldBeTrue)
+	c.start(chess.White)
+	ft.add(10 * time.Second)
+	c.moved(chess.White)
+
+	cmd := c.limit(uci.CmdGo{MoveTime: time.Second})
+	test.That(t, cmd.WhiteTime, test.ShouldEqual, 293*time.Second)
+	test.That(t, cmd.BlackTime, test.ShouldEqual, 60*time.Second)
+	test.That(t, cmd.WhiteIncrement, test.ShouldEqual, 3*time.Second)
+	test.That(t, cmd.BlackIncrement, test.ShouldEqual, time.Duration(0))
+	test.That(t, c.toMap()["black_increment_ms"], test.ShouldEqual, int64(0))
+}
+
+func TestNilGameClock(t *testing.T) {
+	var c *gameClock
+	c.start(chess.White)
+	c.moved(chess.White)
+	c.stop()
+	over, _ := c.over()
+	test.That(t, over, test.ShouldBeFalse)
+	test.That(t, c.limit(uci.CmdGo{MoveTime: time.Second}).MoveTime, test.ShouldEqual, time.Second)
+}
+
+func TestTimeControlValidate(t *testing.T) {
+	test.That(t, (&TimeControl{BaseSeconds: 300, IncrementSeconds: 3}).Validate("x"), test.ShouldBeNil)
+	test.That(t, (&TimeControl{}).Validate("x"), test.ShouldNotBeNil)
+	test.That(t, (&TimeControl{BaseSeconds: 1, IncrementSeconds: -1}).Validate("x"), test.ShouldNotBeNil)
+}
@@ -0,0 +1,191 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os/exec"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/utils/trace"
+)
+
+// PlayerConfig is everything about how one side picks its moves.
+type PlayerConfig struct {
+	Engine        string   `json:"engine"`
+	EngineMillis  int      `json:"engine-millis"`
+	EngineDepth   int      `json:"engine-depth"`   // optional cap on search depth
+	EngineNodes   int      `json:"engine-nodes"`   // optional cap on nodes searched
+	Skill         *float64 `json:"skill"`          // 0-100
+	FallbackDepth int      `json:"fallback-depth"` // for the built in engine
+
+	Book      string `json:"book"`       // polyglot .bin
+	BookDepth int    `json:"book-depth"` // full moves to play from the book
+
+	TimeControl *TimeControl `json:"time-control"` // this side's clock in exhibition mode, instead of the top level one
+}
+
+func (cfg *PlayerConfig) engine() string {
+	if cfg.Engine == "" {
+		return "stockfish"
+	}
+	return cfg.Engine
+}
+
+func (cfg *PlayerConfig) fallbackDepth() int {
+	if cfg.FallbackDepth <= 0 {
+		return defaultFallbackDepth
+	}
+	return cfg.FallbackDepth
+}
+
+func (cfg *PlayerConfig) bookDepth() int {
+	if cfg.BookDepth <= 0 {
+		return defaultBookDepth
+	}
+	return cfg.BookDepth
+}
+
+func (cfg *PlayerConfig) engineMillis() int {
+	if cfg.EngineMillis <= 0 {
+		return 10
+	}
+	return cfg.EngineMillis
+}
+
+func (cfg *PlayerConfig) skill() float64 {
+	if cfg.Skill == nil {
+		return defaultSkill
+	}
+	return *cfg.Skill
+}
+
+func (cfg *PlayerConfig) Validate(path string) error {
+	if cfg.skill() < 0 || cfg.skill() > 100 {
+		return fmt.Errorf("%s: skill has to be between 0 and 100, not %v", path, cfg.skill())
+	}
+	if cfg.TimeControl != nil {
+		return cfg.TimeControl.Validate(path + ".time-control")
+	}
+	return nil
+}
+
+// enginePlayer picks moves for one side: from the book, then the uci engine, or the built in one if there isn't any.
+type enginePlayer struct {
+	conf   *PlayerConfig
+	logger logging.Logger
+	rng    *rand.Rand
+
+	engine   *engineSupervisor // nil when there's no uci engine, then we use fallback
+	fallback *fallbackEngine
+	book     *openingBook
+
+	strength        engineStrength
+	strengthOptions []uci.CmdSetOption
+}
+
+func newEnginePlayer(conf *PlayerConfig, logger logging.Logger, rng *rand.Rand) (*enginePlayer, error) {
+	p := &enginePlayer{
+		conf:     conf,
+		logger:   logger,
+		rng:      rng,
+		fallback: &fallbackEngine{depth: conf.fallbackDepth()},
+		strength: newEngineStrength(conf.skill(), conf.EngineDepth, conf.EngineNodes),
+	}
+
+	var err error
+	if conf.Book != "" {
+		p.book, err = loadOpeningBook(conf.Book, conf.bookDepth())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.engine() == builtinEngine {
+		logger.Infof("using the built in engine")
+	} else if _, err := exec.LookPath(conf.engine()); err != nil {
+		logger.Warnf("can't find engine %s, using the built in one: %v", conf.engine(), err)
+	} else {
+		p.engine, err = newEngineSupervisor(conf.engine(), logger, p.setupEngine)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// pickMove finds a move for the side to move, using the clock for the engine's time if there is one.
+func (p *enginePlayer) pickMove(ctx context.Context, game *chess.Game, clock *gameClock) (*chess.Move, error) {
+	ctx, span := trace.StartSpan(ctx, "pickMove")
+	defer span.End()
+
+	if p.book != nil {
+		m, err := p.book.move(game.Position(), p.rng)
+		if err != nil {
+			p.logger.Warnf("can't use book: %v", err)
+		} else if m != nil {
+			p.logger.Infof("book move: %v", m)
+			return m, nil
+		}
+	}
+
+	if p.engine == nil {
+		depth := p.conf.fallbackDepth()
+		if p.strength.depth > 0 {
+			depth = min(depth, p.strength.depth)
+		}
+		m, _, err := p.fallback.bestMove(ctx, game.Position(), depth)
+		return m, err
+	}
+
+	cmdPos := uci.CmdPosition{Position: game.Position()}
+	cmdGo := p.strength.limit(clock.limit(uci.CmdGo{MoveTime: time.Millisecond * time.Duration(p.conf.engineMillis())}))
+	results, err := p.engine.search(ctx, cmdPos, cmdGo)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.strength.choose(results, p.rng), nil
+}
+
+func (p *enginePlayer) setStrength(skill float64) error {
+	if skill < 0 || skill > 100 {
+		return fmt.Errorf("skill has to be between 0 and 100, not %v", skill)
+	}
+
+	p.strength = newEngineStrength(skill, p.conf.EngineDepth, p.conf.EngineNodes)
+	if p.engine == nil {
+		return nil
+	}
+	return p.setupEngine(p.engine)
+}
+
+// setupEngine sends the strength options, the supervisor calls it on every new engine too.
+func (p *enginePlayer) setupEngine(engine uciRunner) error {
+	opts, err := p.strength.apply(engine)
+	if err != nil {
+		return err
+	}
+	p.strengthOptions = opts
+	p.logger.Infof("engine strength: %v", p.strength.toMap(opts))
+	return nil
+}
+
+func (p *enginePlayer) status() map[string]interface{} {
+	if p.engine != nil {
+		return p.engine.status()
+	}
+	return map[string]interface{}{"path": builtinEngine, "depth": p.conf.fallbackDepth()}
+}
+
+func (p *enginePlayer) Close() error {
+	if p.engine == nil {
+		return nil
+	}
+	return p.engine.Close()
+}
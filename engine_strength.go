@@ -0,0 +1,135 @@
+package viamchess
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+)
+
+const defaultSkill = 50.0
+
+// engineStrength is how hard the engine tries, derived from a 0-100 skill.
+type engineStrength struct {
+	skill float64
+
+	skillLevel    int // stockfish "Skill Level", 0-20
+	limitStrength bool
+	elo           int
+	depth         int     // 0 for no limit
+	nodes         int     // 0 for no limit
+	multiPV       int     // lines searched, more than 1 to have something to blunder with
+	blunderChance float64 // odds of playing one of the other lines instead of the best
+}
+
+// newEngineStrength maps skill onto engine settings. depth and nodes, if set, cap the search regardless of skill.
+func newEngineStrength(skill float64, depth, nodes int) engineStrength {
+	skill = math.Max(0, math.Min(100, skill))
+
+	es := engineStrength{
+		skill:         skill,
+		skillLevel:    int(math.Round(skill / 5)),
+		limitStrength: skill < 100,
+		elo:           1350 + int(skill*15),
+		depth:         depth,
+		nodes:         nodes,
+		multiPV:       1,
+	}
+
+	if skill < 50 {
+		d := 1 + int(skill/5)
+		if es.depth <= 0 || d < es.depth {
+			es.depth = d
+		}
+	}
+
+	if skill < 30 {
+		es.multiPV = 4
+		es.blunderChance = (30 - skill) / 100
+	}
+
+	return es
+}
+
+// options are the uci options for this strength. UCI_Elo is clamped to what the engine advertises.
+// The uci package drops options with spaces in their names, so "Skill Level" is always sent;
+// engines ignore options they don't have.
+func (es engineStrength) options(advertised map[string]uci.Option) []uci.CmdSetOption {
+	opts := []uci.CmdSetOption{
+		{Name: "Skill Level", Value: strconv.Itoa(es.skillLevel)},
+	}
+
+	if _, ok := advertised["UCI_LimitStrength"]; ok {
+		opts = append(opts, uci.CmdSetOption{Name: "UCI_LimitStrength", Value: strconv.FormatBool(es.limitStrength)})
+	}
+
+	if o, ok := advertised["UCI_Elo"]; ok && es.limitStrength {
+		elo := es.elo
+		if lo, err := strconv.Atoi(o.Min); err == nil && elo < lo {
+			elo = lo
+		}
+		if hi, err := strconv.Atoi(o.Max); err == nil && elo > hi {
+			elo = hi
+		}
+		opts = append(opts, uci.CmdSetOption{Name: "UCI_Elo", Value: strconv.Itoa(elo)})
+	}
+
+	if _, ok := advertised["MultiPV"]; ok {
+		opts = append(opts, uci.CmdSetOption{Name: "MultiPV", Value: strconv.Itoa(es.multiPV)})
+	}
+
+	return opts
+}
+
+// apply sends the options to the engine.
+func (es engineStrength) apply(engine uciRunner) ([]uci.CmdSetOption, error) {
+	opts := es.options(engine.Options())
+	for _, o := range opts {
+		err := engine.Run(o)
+		if err != nil {
+			return nil, fmt.Errorf("can't set engine option %s: %w", o.Name, err)
+		}
+	}
+	return opts, engine.Run(uci.CmdIsReady)
+}
+
+func (es engineStrength) limit(cmdGo uci.CmdGo) uci.CmdGo {
+	cmdGo.Depth = es.depth
+	cmdGo.Nodes = es.nodes
+	return cmdGo
+}
+
+// choose picks the best move, or now and then, one of the other lines the engine found.
+func (es engineStrength) choose(results uci.SearchResults, rng *rand.Rand) *chess.Move {
+	if es.multiPV <= 1 || rng.Float64() >= es.blunderChance {
+		return results.BestMove
+	}
+
+	others := []*chess.Move{}
+	for _, info := range results.MultiPVInfo[1:] {
+		if len(info.PV) > 0 {
+			others = append(others, info.PV[0])
+		}
+	}
+	if len(others) == 0 {
+		return results.BestMove
+	}
+	return others[rng.Intn(len(others))]
+}
+
+func (es engineStrength) toMap(opts []uci.CmdSetOption) map[string]interface{} {
+	options := map[string]interface{}{}
+	for _, o := range opts {
+		options[o.Name] = o.Value
+	}
+	return map[string]interface{}{
+		"skill":          es.skill,
+		"depth":          es.depth,
+		"nodes":          es.nodes,
+		"blunder_chance": es.blunderChance,
+		"options":        options,
+	}
+}
@@ -0,0 +1,95 @@
+package viamchess
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+
+	"go.viam.com/test"
+)
+
+func TestEngineStrength(t *testing.T) {
+	es := newEngineStrength(100, 0, 0)
+	test.That(t, es.skillLevel, test.ShouldEqual, 20)
+	test.That(t, es.limitStrength, test.ShouldBeFalse)
+	test.That(t, es.depth, test.ShouldEqual, 0)
+	test.That(t, es.multiPV, test.ShouldEqual, 1)
+
+	es = newEngineStrength(50, 0, 5000)
+	test.That(t, es.skillLevel, test.ShouldEqual, 10)
+	test.That(t, es.limitStrength, test.ShouldBeTrue)
+	test.That(t, es.elo, test.ShouldEqual, 2100)
+	test.That(t, es.nodes, test.ShouldEqual, 5000)
+	test.That(t, es.blunderChance, test.ShouldEqual, 0)
+
+	es = newEngineStrength(10, 0, 0)
+	test.That(t, es.skillLevel, test.ShouldEqual, 2)
+	test.That(t, es.depth, test.ShouldEqual, 3)
+	test.That(t, es.multiPV, test.ShouldEqual, 4)
+	test.That(t, es.blunderChance, test.ShouldAlmostEqual, .2)
+
+	// configured depth wins when it's lower
+	test.That(t, newEngineStrength(10, 2, 0).depth, test.ShouldEqual, 2)
+	test.That(t, newEngineStrength(80, 12, 0).depth, test.ShouldEqual, 12)
+
+	test.That(t, newEngineStrength(500, 0, 0).skill, test.ShouldEqual, 100)
+}
+
+func TestEngineStrengthOptions(t *testing.T) {
+	es := newEngineStrength(0, 0, 0)
+
+	test.That(t, es.options(nil), test.ShouldResemble, []uci.CmdSetOption{
+		{Name: "Skill Level", Value: "0"},
+	})
+
+	advertised := map[string]uci.Option{
+		"UCI_LimitStrength": {Name: "UCI_LimitStrength", Type: uci.OptionCheck},
+		"UCI_Elo":           {Name: "UCI_Elo", Type: uci.OptionSpin, Min: "1400", Max: "2850"},
+		"MultiPV":           {Name: "MultiPV", Type: uci.OptionSpin, Min: "1", Max: "500"},
+	}
+	test.That(t, es.options(advertised), test.ShouldResemble, []uci.CmdSetOption{
+		{Name: "Skill Level", Value: "0"},
+		{Name: "UCI_LimitStrength", Value: "true"},
+		{Name: "UCI_Elo", Value: "1400"},
+		{Name: "MultiPV", Value: "4"},
+	})
+
+	test.That(t, newEngineStrength(100, 0, 0).options(advertised), test.ShouldResemble, []uci.CmdSetOption{
+		{Name: "Skill Level", Value: "20"},
+		{Name: "UCI_LimitStrength", Value: "false"},
+		{Name: "MultiPV", Value: "1"},
+	})
+}
+
+func TestEngineStrengthChoose(t *testing.T) {
+	moves := chess.NewGame().ValidMoves()
+	results := uci.SearchResults{
+		BestMove: &moves[0],
+		MultiPVInfo: []uci.Info{
+			{PV: []*chess.Move{&moves[0]}},
+			{PV: []*chess.Move{&moves[1]}},
+			{PV: []*chess.Move{&moves[2]}},
+		},
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	strong := newEngineStrength(90, 0, 0)
+	for range 50 {
+		test.That(t, strong.choose(results, rng), test.ShouldEqual, &moves[0])
+	}
+
+	weak := newEngineStrength(0, 0, 0)
+	blunders := 0
+	for range 1000 {
+		if weak.choose(results, rng) != &moves[0] {
+			blunders++
+		}
+	}
+	test.That(t, blunders, test.ShouldBeBetween, 200, 400)
+
+	// nothing else to pick
+	test.That(t, weak.choose(uci.SearchResults{BestMove: &moves[0], MultiPVInfo: make([]uci.Info, 1)}, rng), test.ShouldEqual, &moves[0])
+}
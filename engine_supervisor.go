@@ -0,0 +1,306 @@
+package viamchess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/logging"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+)
+
+const engineTimeout = 5 * time.Second
+
+var errEngineHung = errors.New("engine not responding")
+
+// uciRunner is what we need from an engine, so strength can be applied to either a raw engine or a supervised one.
+type uciRunner interface {
+	Options() map[string]uci.Option
+	Run(cmds ...uci.Cmd) error
+}
+
+// engineSupervisor owns the engine process. Every command gets a timeout, a hung or dead engine
+// is killed and a new one started on the next command.
+type engineSupervisor struct {
+	path    string
+	logger  logging.Logger
+	timeout time.Duration
+	setup   func(uciRunner) error // run on every new engine, after uci/isready
+
+	startMu sync.Mutex // held while starting an engine
+
+	mu            sync.Mutex
+	engine        *uciProcess
+	started       time.Time
+	restarts      int
+	lastErr       error
+	lastErrTime   time.Time
+	lastSearchErr error
+}
+
+func newEngineSupervisor(path string, logger logging.Logger, setup func(uciRunner) error) (*engineSupervisor, error) {
+	es := &engineSupervisor{
+		path:    path,
+		logger:  logger,
+		timeout: engineTimeout,
+		setup:   setup,
+	}
+
+	_, err := es.current()
+	if err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+// current returns the running engine, starting one if needed. Starting is done outside of mu,
+// so status doesn't wait on a slow engine, and startMu keeps two from starting at once.
+func (es *engineSupervisor) current() (*uciProcess, error) {
+	e := es.running()
+	if e != nil {
+		return e, nil
+	}
+
+	es.startMu.Lock()
+	defer es.startMu.Unlock()
+
+	e = es.running()
+	if e != nil {
+		return e, nil // someone else started it
+	}
+
+	e, err := es.start()
+	if err != nil {
+		es.mu.Lock()
+		es.recordLocked(err)
+		es.mu.Unlock()
+		return nil, err
+	}
+
+	es.mu.Lock()
+	es.engine = e
+	es.started = time.Now()
+	es.mu.Unlock()
+	return e, nil
+}
+
+func (es *engineSupervisor) running() *uciProcess {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return es.engine
+}
+
+// start runs a new engine and gets it ready.
+func (es *engineSupervisor) start() (*uciProcess, error) {
+	e, err := startUCI(es.path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = es.wait(context.Background(), e, 0, uci.CmdUCI, uci.CmdIsReady, uci.CmdUCINewGame)
+	if err == nil && es.setup != nil {
+		err = es.setup(&pinnedEngine{es: es, e: e})
+	}
+	if err != nil {
+		e.kill()
+		return nil, fmt.Errorf("can't start engine %s: %w", es.path, err)
+	}
+	return e, nil
+}
+
+// wait runs cmds on e, giving up after limit plus the timeout. A search is told to stop if ctx is
+// cancelled or it runs long, and only counts as hung if it doesn't stop.
+func (es *engineSupervisor) wait(ctx context.Context, e *uciProcess, limit time.Duration, cmds ...uci.Cmd) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Run(cmds...)
+	}()
+
+	timer := time.NewTimer(limit + es.timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if !es.stop(e, done) {
+			return errEngineHung
+		}
+		return ctx.Err()
+	case <-timer.C:
+		if !es.stop(e, done) {
+			return errEngineHung
+		}
+		return nil
+	}
+}
+
+// stop sends "stop" and waits for the running command to finish.
+func (es *engineSupervisor) stop(e *uciProcess, done chan error) bool {
+	go func() {
+		err := e.Run(uci.CmdStop)
+		if err != nil {
+			es.logger.Debugf("can't send stop to engine: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(es.timeout):
+		return false
+	}
+}
+
+// run runs cmds on the current engine, restarting it if it fails.
+func (es *engineSupervisor) run(ctx context.Context, limit time.Duration, cmds ...uci.Cmd) error {
+	e, err := es.current()
+	if err != nil {
+		return err
+	}
+
+	err = es.wait(ctx, e, limit, cmds...)
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		es.failed(e, err)
+	}
+	return err
+}
+
+// failed kills a broken engine, the next command starts a new one.
+func (es *engineSupervisor) failed(e *uciProcess, err error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.recordLocked(err)
+	if es.engine != e {
+		return // already replaced
+	}
+
+	es.logger.Warnf("engine failed, restarting: %v", err)
+	e.kill()
+	es.engine = nil
+	es.restarts++
+}
+
+func (es *engineSupervisor) recordLocked(err error) {
+	es.lastErr = err
+	es.lastErrTime = time.Now()
+}
+
+// check makes sure the engine answers isready.
+func (es *engineSupervisor) check(ctx context.Context) error {
+	return es.run(ctx, 0, uci.CmdIsReady)
+}
+
+// search health checks the engine and runs a search, trying once more on a fresh engine if it fails.
+func (es *engineSupervisor) search(ctx context.Context, pos uci.CmdPosition, cmdGo uci.CmdGo) (uci.SearchResults, error) {
+	var err error
+	for range 2 {
+		err = es.check(ctx)
+		if err == nil {
+			err = es.searchOnce(ctx, pos, cmdGo)
+		}
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	es.mu.Lock()
+	es.lastSearchErr = err
+	es.mu.Unlock()
+
+	if err != nil {
+		return uci.SearchResults{}, err
+	}
+	return es.SearchResults()
+}
+
+func (es *engineSupervisor) searchOnce(ctx context.Context, pos uci.CmdPosition, cmdGo uci.CmdGo) error {
+	limit := cmdGo.MoveTime
+	if limit == 0 { // on a clock, it can't think longer than it has left
+		limit = cmdGo.WhiteTime
+		if pos.Position.Turn() == chess.Black {
+			limit = cmdGo.BlackTime
+		}
+	}
+	return es.run(ctx, limit, pos, cmdGo)
+}
+
+func (es *engineSupervisor) SearchResults() (uci.SearchResults, error) {
+	e, err := es.current()
+	if err != nil {
+		return uci.SearchResults{}, err
+	}
+	res := e.SearchResults()
+	if res.BestMove == nil {
+		return res, fmt.Errorf("engine didn't find a move")
+	}
+	return res, nil
+}
+
+// Options and Run make the supervisor a uciRunner.
+func (es *engineSupervisor) Options() map[string]uci.Option {
+	e, err := es.current()
+	if err != nil {
+		return nil
+	}
+	return e.Options()
+}
+
+func (es *engineSupervisor) Run(cmds ...uci.Cmd) error {
+	return es.run(context.Background(), 0, cmds...)
+}
+
+func (es *engineSupervisor) status() map[string]interface{} {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	m := map[string]interface{}{
+		"path":     es.path,
+		"running":  es.engine != nil,
+		"restarts": es.restarts,
+	}
+	if es.engine != nil {
+		m["pid"] = es.engine.pid()
+		m["uptime_sec"] = time.Since(es.started).Seconds()
+	}
+	if es.lastErr != nil {
+		m["last_error"] = es.lastErr.Error()
+		m["last_error_time"] = es.lastErrTime.Format(time.RFC3339)
+	}
+	if es.lastSearchErr != nil {
+		m["last_search_error"] = es.lastSearchErr.Error()
+	}
+	return m
+}
+
+func (es *engineSupervisor) Close() error {
+	es.mu.Lock()
+	e := es.engine
+	es.engine = nil
+	es.mu.Unlock()
+
+	if e == nil {
+		return nil
+	}
+
+	return e.close(es.timeout)
+}
+
+// pinnedEngine runs commands on one engine with the supervisor's timeout, used while it's starting.
+type pinnedEngine struct {
+	es *engineSupervisor
+	e  *uciProcess
+}
+
+func (p *pinnedEngine) Options() map[string]uci.Option {
+	return p.e.Options()
+}
+
+func (p *pinnedEngine) Run(cmds ...uci.Cmd) error {
+	return p.es.wait(context.Background(), p.e, 0, cmds...)
+}
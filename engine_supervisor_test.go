@@ -0,0 +1,127 @@
+package viamchess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+// fakeEngine writes a shell script that speaks just enough uci. onGo runs when a search starts.
+func fakeEngine(t *testing.T, onGo string) string {
+	t.Helper()
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "engine")
+	script := `#!/bin/sh
+cd ` + dir + `
+while read line; do
+	case "$line" in
+		uci) echo "id name fake"; echo "option name MultiPV type spin default 1 min 1 max 500"; echo uciok ;;
+		isready) echo readyok ;;
+		go*) ` + onGo + ` ;;
+		stop) echo "bestmove e2e4" ;;
+		quit) exit 0 ;;
+	esac
+done
+`
+	test.That(t, os.WriteFile(fn, []byte(script), 0o755), test.ShouldBeNil)
+	return fn
+}
+
+func testSearch() (uci.CmdPosition, uci.CmdGo) {
+	return uci.CmdPosition{Position: chess.StartingPosition()}, uci.CmdGo{MoveTime: 10 * time.Millisecond}
+}
+
+func TestEngineSupervisorSearch(t *testing.T) {
+	setups := 0
+	es, err := newEngineSupervisor(fakeEngine(t, `echo "bestmove d2d4"`), logging.NewTestLogger(t), func(r uciRunner) error {
+		setups++
+		_, ok := r.Options()["MultiPV"]
+		test.That(t, ok, test.ShouldBeTrue)
+		return nil
+	})
+	test.That(t, err, test.ShouldBeNil)
+	defer es.Close()
+
+	pos, cmdGo := testSearch()
+	res, err := es.search(context.Background(), pos, cmdGo)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res.BestMove.String(), test.ShouldEqual, "d2d4")
+	test.That(t, setups, test.ShouldEqual, 1)
+	test.That(t, es.status()["restarts"], test.ShouldEqual, 0)
+}
+
+func TestEngineSupervisorCancel(t *testing.T) {
+	// searches until told to stop
+	es, err := newEngineSupervisor(fakeEngine(t, `:`), logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer es.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	pos, cmdGo := testSearch()
+	cmdGo.MoveTime = time.Hour
+	_, err = es.search(ctx, pos, cmdGo)
+	test.That(t, errors.Is(err, context.DeadlineExceeded), test.ShouldBeTrue)
+
+	// still the same engine, and it still works
+	test.That(t, es.status()["restarts"], test.ShouldEqual, 0)
+	test.That(t, es.check(context.Background()), test.ShouldBeNil)
+}
+
+func TestEngineSupervisorRestart(t *testing.T) {
+	// the first engine dies on its first search
+	es, err := newEngineSupervisor(
+		fakeEngine(t, `if [ -e crashed ]; then echo "bestmove g1f3"; else touch crashed; exit 1; fi`),
+		logging.NewTestLogger(t),
+		nil,
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer es.Close()
+	es.timeout = 200 * time.Millisecond
+
+	pos, cmdGo := testSearch()
+	res, err := es.search(context.Background(), pos, cmdGo)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res.BestMove.String(), test.ShouldEqual, "g1f3")
+
+	status := es.status()
+	test.That(t, status["restarts"], test.ShouldEqual, 1)
+	test.That(t, status["running"], test.ShouldBeTrue)
+	test.That(t, status["last_error"], test.ShouldNotBeEmpty)
+	_, ok := status["last_search_error"]
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestEngineSupervisorMissing(t *testing.T) {
+	_, err := newEngineSupervisor(filepath.Join(t.TempDir(), "nope"), logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestEngineSupervisorKill(t *testing.T) {
+	// hangs on every search, not even reading stop
+	es, err := newEngineSupervisor(fakeEngine(t, `exec sleep 60`), logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer es.Close()
+	es.timeout = 200 * time.Millisecond
+
+	pid := es.status()["pid"].(int)
+
+	pos, cmdGo := testSearch()
+	_, err = es.search(context.Background(), pos, cmdGo)
+	test.That(t, errors.Is(err, errEngineHung), test.ShouldBeTrue)
+	test.That(t, es.status()["restarts"], test.ShouldEqual, 2)
+
+	// gone, not a zombie
+	test.That(t, syscall.Kill(pid, 0), test.ShouldEqual, syscall.ESRCH)
+}
@@ -0,0 +1,282 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"go.uber.org/multierr"
+)
+
+// ExhibitionConfig is for the robot playing itself, each side with its own engine.
+type ExhibitionConfig struct {
+	White       PlayerConfig `json:"white"`
+	Black       PlayerConfig `json:"black"`
+	PauseMillis int          `json:"pause-millis"` // between moves, so people can watch
+	MaxMoves    int          `json:"max-moves"`    // in plies, so two engines shuffling pieces stop eventually
+}
+
+func (cfg *ExhibitionConfig) pause() time.Duration {
+	if cfg.PauseMillis <= 0 {
+		return 3 * time.Second
+	}
+	return time.Duration(cfg.PauseMillis) * time.Millisecond
+}
+
+func (cfg *ExhibitionConfig) maxMoves() int {
+	if cfg.MaxMoves <= 0 {
+		return 300
+	}
+	return cfg.MaxMoves
+}
+
+// timed says if either side has its own time control.
+func (cfg *ExhibitionConfig) timed() bool {
+	return cfg != nil && (cfg.White.TimeControl != nil || cfg.Black.TimeControl != nil)
+}
+
+// timeControls is each side's clock, their own or else fallback.
+func (cfg *ExhibitionConfig) timeControls(fallback *TimeControl) (*TimeControl, *TimeControl) {
+	white, black := fallback, fallback
+	if cfg.White.TimeControl != nil {
+		white = cfg.White.TimeControl
+	}
+	if cfg.Black.TimeControl != nil {
+		black = cfg.Black.TimeControl
+	}
+	return white, black
+}
+
+func (cfg *ExhibitionConfig) Validate(path string) error {
+	if err := cfg.White.Validate(path + ".white"); err != nil {
+		return err
+	}
+	return cfg.Black.Validate(path + ".black")
+}
+
+type ExhibitionCmd struct {
+	Start bool
+	Stop  bool
+}
+
+type exhibitionMove struct {
+	ply    int
+	color  chess.Color
+	move   string
+	engine string
+	took   time.Duration
+	at     time.Time
+}
+
+func (m exhibitionMove) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ply":     m.ply,
+		"color":   m.color.Name(),
+		"move":    m.move,
+		"engine":  m.engine,
+		"took_ms": m.took.Milliseconds(),
+		"at":      m.at.Format(time.RFC3339),
+	}
+}
+
+// exhibitionSession is one game of the robot against itself.
+type exhibitionSession struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	status string
+	err    error
+	moves  []exhibitionMove
+}
+
+func (e *exhibitionSession) setStatus(status string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status = status
+	e.err = err
+}
+
+func (e *exhibitionSession) toMap() map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	moves := []interface{}{}
+	for _, m := range e.moves {
+		moves = append(moves, m.toMap())
+	}
+
+	m := map[string]interface{}{
+		"status": e.status,
+		"moves":  moves,
+	}
+	if e.err != nil {
+		m["error"] = e.err.Error()
+	}
+	return m
+}
+
+func (s *viamChessChess) doExhibition(cmd ExhibitionCmd) (map[string]interface{}, error) {
+	if cmd.Stop {
+		s.stopExhibition()
+		return map[string]interface{}{"exhibition": "stopped"}, nil
+	}
+
+	if cmd.Start {
+		err := s.startExhibition()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.playLock.Lock()
+	defer s.playLock.Unlock()
+	if s.exhibition == nil {
+		return map[string]interface{}{"exhibition": "stopped"}, nil
+	}
+	return s.exhibition.toMap(), nil
+}
+
+func (s *viamChessChess) startExhibition() error {
+	if s.conf.Exhibition == nil {
+		return fmt.Errorf("no exhibition config")
+	}
+
+	s.stopPlay()
+	s.stopExhibition()
+
+	white, err := newEnginePlayer(&s.conf.Exhibition.White, s.logger.Sublogger("white"), s.rng)
+	if err != nil {
+		return fmt.Errorf("can't start white: %w", err)
+	}
+	black, err := newEnginePlayer(&s.conf.Exhibition.Black, s.logger.Sublogger("black"), s.rng)
+	if err != nil {
+		return multierr.Combine(fmt.Errorf("can't start black: %w", err), white.Close())
+	}
+
+	ctx, cancel := context.WithCancel(s.cancelCtx)
+	e := &exhibitionSession{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: "starting",
+	}
+
+	s.playLock.Lock()
+	s.exhibition = e
+	s.playLock.Unlock()
+
+	timed := s.conf.Exhibition.timed()
+	if timed {
+		s.clock.use(s.conf.Exhibition.timeControls(s.conf.TimeControl))
+	}
+
+	go func() {
+		defer close(e.done)
+		defer func() {
+			if timed { // back to the clock play mode uses
+				s.clock.use(s.conf.TimeControl, s.conf.TimeControl)
+			}
+		}()
+		defer s.clock.stop()
+		defer func() {
+			err := multierr.Combine(white.Close(), black.Close())
+			if err != nil {
+				s.logger.Warnf("can't close exhibition engines: %v", err)
+			}
+		}()
+		s.exhibitionLoop(ctx, e, map[chess.Color]*enginePlayer{chess.White: white, chess.Black: black})
+	}()
+
+	return nil
+}
+
+func (s *viamChessChess) stopExhibition() {
+	s.playLock.Lock()
+	e := s.exhibition
+	s.exhibition = nil
+	s.playLock.Unlock()
+
+	if e == nil {
+		return
+	}
+	e.cancel()
+	<-e.done
+}
+
+func (s *viamChessChess) exhibitionLoop(ctx context.Context, e *exhibitionSession, players map[chess.Color]*enginePlayer) {
+	pause := s.conf.Exhibition.pause()
+
+	for range s.conf.Exhibition.maxMoves() {
+		over, err := s.exhibitionStep(ctx, e, players)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// someone has to fix the board, don't keep moving pieces around
+			s.logger.Errorf("exhibition stopped: %v", err)
+			e.setStatus("stopped", err)
+			return
+		}
+		if over {
+			return
+		}
+
+		s.clock.stop() // the pause is for the audience, not on anyone's clock
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pause):
+		}
+	}
+
+	e.setStatus("stopped: too many moves", nil)
+}
+
+// exhibitionStep makes one move for whoever's turn it is. Returns true when the game is over.
+func (s *viamChessChess) exhibitionStep(ctx context.Context, e *exhibitionSession, players map[chess.Color]*enginePlayer) (bool, error) {
+	s.doCommandLock.Lock()
+	defer s.doCommandLock.Unlock()
+
+	theState, err := s.getGame(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if over, result := s.isOver(theState.game); over {
+		s.logger.Infof("exhibition over: %s", result)
+		e.setStatus("game over: "+result, nil)
+		return true, nil
+	}
+
+	turn := theState.game.Position().Turn()
+	s.clock.start(turn)
+	player := players[turn]
+	e.setStatus(turn.Name()+" to move", nil)
+
+	start := time.Now()
+	m, _, err := s.makeAMove(ctx, player, false)
+	if err != nil {
+		return false, err
+	}
+	took := time.Since(start)
+
+	theState, err = s.getGame(ctx) // makeAMove saved the move into a new one
+	if err != nil {
+		return false, err
+	}
+
+	e.mu.Lock()
+	e.moves = append(e.moves, exhibitionMove{
+		ply:    theState.game.Position().Ply(),
+		color:  turn,
+		move:   m.String(),
+		engine: player.conf.engine(),
+		took:   took,
+		at:     time.Now(),
+	})
+	e.mu.Unlock()
+
+	return false, s.goToStart(ctx)
+}
@@ -0,0 +1,56 @@
+package viamchess
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/test"
+)
+
+func TestExhibitionConfig(t *testing.T) {
+	cfg := &ExhibitionConfig{}
+	test.That(t, cfg.pause(), test.ShouldEqual, 3*time.Second)
+	test.That(t, cfg.maxMoves(), test.ShouldEqual, 300)
+	test.That(t, cfg.Validate("x"), test.ShouldBeNil)
+
+	bad := 120.0
+	cfg.Black.Skill = &bad
+	err := cfg.Validate("x")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "x.black")
+	cfg.Black.Skill = nil
+
+	// each side can have its own clock, the other falls back to the top level one
+	test.That(t, cfg.timed(), test.ShouldBeFalse)
+	top := &TimeControl{BaseSeconds: 300}
+	cfg.Black.TimeControl = &TimeControl{BaseSeconds: 60, IncrementSeconds: 1}
+	test.That(t, cfg.timed(), test.ShouldBeTrue)
+	white, black := cfg.timeControls(top)
+	test.That(t, white, test.ShouldEqual, top)
+	test.That(t, black, test.ShouldEqual, cfg.Black.TimeControl)
+
+	cfg.Black.TimeControl = &TimeControl{}
+	test.That(t, cfg.Validate("x"), test.ShouldNotBeNil)
+}
+
+func TestExhibitionSessionMap(t *testing.T) {
+	e := &exhibitionSession{status: "white to move"}
+	m := e.toMap()
+	test.That(t, m["status"], test.ShouldEqual, "white to move")
+	test.That(t, m["moves"], test.ShouldHaveLength, 0)
+
+	e.moves = append(e.moves, exhibitionMove{ply: 1, color: chess.White, move: "e2e4", engine: "stockfish", took: 1500 * time.Millisecond})
+	e.setStatus("stopped", errors.New("no gripper"))
+
+	m = e.toMap()
+	test.That(t, m["error"], test.ShouldEqual, "no gripper")
+	moves := m["moves"].([]interface{})
+	test.That(t, moves, test.ShouldHaveLength, 1)
+	move := moves[0].(map[string]interface{})
+	test.That(t, move["color"], test.ShouldEqual, "White")
+	test.That(t, move["move"], test.ShouldEqual, "e2e4")
+	test.That(t, move["took_ms"], test.ShouldEqual, int64(1500))
+}
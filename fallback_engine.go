@@ -0,0 +1,264 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/corentings/chess/v2"
+)
+
+const (
+	defaultFallbackDepth = 3
+	mateScore            = 100000
+	quiescenceDepth      = 4
+)
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+// piece square tables from white's side, a8 first, h1 last
+var pieceSquareTables = map[chess.PieceType][64]int{
+	chess.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	chess.Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	chess.Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	chess.Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	chess.Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	chess.King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+// fallbackEngine is a small alpha-beta search for when there's no uci engine to run.
+// It's deterministic: the same position always gets the same move.
+type fallbackEngine struct {
+	depth int
+}
+
+// evaluate scores the position in centipawns for the side to move.
+func evaluate(pos *chess.Position) int {
+	score := 0
+	for sq, p := range pos.Board().SquareMap() {
+		idx := (7-int(sq.Rank()))*8 + int(sq.File())
+		if p.Color() == chess.Black {
+			idx = int(sq.Rank())*8 + int(sq.File())
+		}
+		v := pieceValues[p.Type()] + pieceSquareTables[p.Type()][idx]
+		if p.Color() == pos.Turn() {
+			score += v
+		} else {
+			score -= v
+		}
+	}
+	return score
+}
+
+// orderMoves puts captures of big pieces by small ones first, which is where alpha-beta cuts the most.
+func orderMoves(pos *chess.Position, moves []chess.Move) {
+	value := func(m *chess.Move) int {
+		v := 0
+		if m.HasTag(chess.Capture) {
+			v += 10*pieceValues[pos.Board().Piece(m.S2()).Type()] - pieceValues[pos.Board().Piece(m.S1()).Type()] + 2000
+		}
+		if m.Promo() != chess.NoPieceType {
+			v += pieceValues[m.Promo()]
+		}
+		return v
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return value(&moves[i]) > value(&moves[j])
+	})
+}
+
+// bestMove searches depth plies, e.depth if 0, and returns the move with its score for the side to move.
+func (e *fallbackEngine) bestMove(ctx context.Context, pos *chess.Position, depth int) (*chess.Move, int, error) {
+	if depth <= 0 {
+		depth = e.depth
+	}
+	if depth <= 0 {
+		depth = defaultFallbackDepth
+	}
+
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		return nil, 0, fmt.Errorf("no valid moves")
+	}
+	orderMoves(pos, moves)
+
+	best := -1
+	alpha := -mateScore - 1
+	for i := range moves {
+		score, err := e.search(ctx, pos.Update(&moves[i]), moves[i].HasTag(chess.Check), depth-1, 1, -mateScore-1, -alpha)
+		if err != nil {
+			return nil, 0, err
+		}
+		score = -score
+		if score > alpha {
+			alpha, best = score, i
+		}
+	}
+
+	return &moves[best], alpha, nil
+}
+
+type scoredMove struct {
+	move  *chess.Move
+	score int // centipawns for the side to move
+}
+
+// topMoves scores every move with a full window and returns the best n, which is slower than bestMove but gives real scores for all of them.
+func (e *fallbackEngine) topMoves(ctx context.Context, pos *chess.Position, depth, n int) ([]scoredMove, error) {
+	if depth <= 0 {
+		depth = e.depth
+	}
+
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("no valid moves")
+	}
+	orderMoves(pos, moves)
+
+	scored := []scoredMove{}
+	for i := range moves {
+		score, err := e.search(ctx, pos.Update(&moves[i]), moves[i].HasTag(chess.Check), depth-1, 1, -mateScore-1, mateScore+1)
+		if err != nil {
+			return nil, err
+		}
+		scored = append(scored, scoredMove{&moves[i], -score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if n > 0 && n < len(scored) {
+		scored = scored[:n]
+	}
+	return scored, nil
+}
+
+func (e *fallbackEngine) search(ctx context.Context, pos *chess.Position, inCheck bool, depth, ply, alpha, beta int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -mateScore + ply, nil // sooner mates are better
+		}
+		return 0, nil
+	}
+
+	if depth <= 0 {
+		return e.quiesce(ctx, pos, moves, quiescenceDepth, ply, alpha, beta)
+	}
+
+	orderMoves(pos, moves)
+	for i := range moves {
+		score, err := e.search(ctx, pos.Update(&moves[i]), moves[i].HasTag(chess.Check), depth-1, ply+1, -beta, -alpha)
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha, nil
+}
+
+// quiesce only looks at captures so we don't stop searching in the middle of a trade.
+func (e *fallbackEngine) quiesce(ctx context.Context, pos *chess.Position, moves []chess.Move, depth, ply, alpha, beta int) (int, error) {
+	standPat := evaluate(pos)
+	if standPat >= beta {
+		return beta, nil
+	}
+	alpha = max(alpha, standPat)
+	if depth <= 0 {
+		return alpha, nil
+	}
+
+	orderMoves(pos, moves)
+	for i := range moves {
+		if !moves[i].HasTag(chess.Capture) {
+			break // captures come first
+		}
+		next := pos.Update(&moves[i])
+		nextMoves := next.ValidMoves()
+		var score int
+		var err error
+		if len(nextMoves) == 0 {
+			score, err = e.search(ctx, next, moves[i].HasTag(chess.Check), 0, ply+1, -beta, -alpha)
+		} else {
+			score, err = e.quiesce(ctx, next, nextMoves, depth-1, ply+1, -beta, -alpha)
+		}
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha, nil
+}
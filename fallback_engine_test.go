@@ -0,0 +1,80 @@
+package viamchess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func positionFromFEN(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	opt, err := chess.FEN(fen)
+	test.That(t, err, test.ShouldBeNil)
+	return chess.NewGame(opt).Position()
+}
+
+func TestEvaluate(t *testing.T) {
+	test.That(t, evaluate(chess.StartingPosition()), test.ShouldEqual, 0)
+
+	// white is up a queen, from either side
+	pos := positionFromFEN(t, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	test.That(t, evaluate(pos), test.ShouldBeGreaterThan, 800)
+	pos = positionFromFEN(t, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1")
+	test.That(t, evaluate(pos), test.ShouldBeLessThan, -800)
+}
+
+func TestFallbackEngine(t *testing.T) {
+	e := &fallbackEngine{depth: 3}
+	ctx := context.Background()
+
+	// back rank mate
+	m, score, err := e.bestMove(ctx, positionFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"), 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldEqual, "a1a8")
+	test.That(t, score, test.ShouldBeGreaterThan, mateScore-10)
+
+	// free queen
+	m, _, err = e.bestMove(ctx, positionFromFEN(t, "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1"), 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldEqual, "d2d5")
+
+	// don't take a defended pawn with the queen
+	m, _, err = e.bestMove(ctx, positionFromFEN(t, "4k3/8/2p5/3p4/8/8/8/3QK3 w - - 0 1"), 2)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldNotEqual, "d1d5")
+
+	_, _, err = e.bestMove(ctx, positionFromFEN(t, "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1"), 0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = e.bestMove(cancelled, chess.StartingPosition(), 0)
+	test.That(t, err, test.ShouldEqual, context.Canceled)
+}
+
+func TestEnginePlayerFallback(t *testing.T) {
+	p, err := newEnginePlayer(&PlayerConfig{Engine: builtinEngine, FallbackDepth: 2}, logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer p.Close()
+
+	opt, err := chess.FEN("6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	test.That(t, err, test.ShouldBeNil)
+	game := chess.NewGame(opt)
+
+	m, err := p.pickMove(context.Background(), game, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldEqual, "a1a8")
+
+	// deterministic
+	m2, err := p.pickMove(context.Background(), chess.NewGame(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	m3, err := p.pickMove(context.Background(), chess.NewGame(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m2.String(), test.ShouldEqual, m3.String())
+
+	test.That(t, p.status()["path"], test.ShouldEqual, builtinEngine)
+}
@@ -0,0 +1,347 @@
+package viamchess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/utils/trace"
+)
+
+const (
+	// stateVersion is the savedState format. 0 is from before there was a version, which reads the same.
+	stateVersion = 1
+	defaultGame  = "default"
+)
+
+var gameNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type GamesCmd struct {
+	List   bool
+	Create string
+	Switch string
+	Delete string
+}
+
+// gameIndex is games.json, which game we're on.
+type gameIndex struct {
+	Version int    `json:"version"`
+	Current string `json:"current"`
+}
+
+// gameStore keeps every game in its own directory: <data>/games/<name>/state.json and journal.json.
+type gameStore struct {
+	dir    string
+	logger logging.Logger
+
+	mu      sync.Mutex
+	current string
+}
+
+// writeFileAtomic writes to a temp file next to fn and renames it over fn, so a crash leaves the old file or the new one, never half of one.
+func writeFileAtomic(fn string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(fn), filepath.Base(fn)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("can't write %s: %w", fn, err)
+	}
+	return os.Rename(f.Name(), fn)
+}
+
+func checkGameName(name string) error {
+	if !gameNameRegexp.MatchString(name) {
+		return fmt.Errorf("bad game name (%s), only letters, numbers, - and _", name)
+	}
+	return nil
+}
+
+// openGameStore opens the games under dataDir, moving the old single state.json in as the default game if it's there.
+func openGameStore(ctx context.Context, dataDir string, logger logging.Logger) (*gameStore, error) {
+	gs := &gameStore{dir: filepath.Join(dataDir, "games"), logger: logger, current: defaultGame}
+
+	err := os.MkdirAll(gs.dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(gs.indexFile())
+	switch {
+	case os.IsNotExist(err):
+		err = gs.migrate(ctx, dataDir)
+		if err != nil {
+			return nil, err
+		}
+		err = gs.writeIndex()
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		idx := gameIndex{}
+		err = json.Unmarshal(data, &idx)
+		if err != nil {
+			return nil, fmt.Errorf("bad game index (%s): %w", gs.indexFile(), err)
+		}
+		if idx.Version > stateVersion {
+			return nil, fmt.Errorf("game index is version %d, this only knows up to %d", idx.Version, stateVersion)
+		}
+		if idx.Current != "" {
+			gs.current = idx.Current
+		}
+	}
+
+	return gs, os.MkdirAll(gs.gameDir(gs.current), 0o755)
+}
+
+// migrate moves state.json and journal.json from before there were games into the default game.
+// They were named by sticking the file name right on the end of the data dir.
+func (gs *gameStore) migrate(ctx context.Context, dataDir string) error {
+	err := os.MkdirAll(gs.gameDir(defaultGame), 0o755)
+	if err != nil {
+		return err
+	}
+
+	oldState := dataDir + "state.json"
+	if _, err := os.Stat(oldState); err == nil {
+		theState, err := readState(ctx, oldState)
+		if err != nil {
+			return fmt.Errorf("can't migrate %s: %w", oldState, err)
+		}
+		err = writeState(filepath.Join(gs.gameDir(defaultGame), "state.json"), theState)
+		if err != nil {
+			return err
+		}
+		gs.logger.Infof("moved %s into game %s", oldState, defaultGame)
+		err = os.Remove(oldState)
+		if err != nil {
+			return err
+		}
+	}
+
+	oldJournal := dataDir + "journal.json"
+	if _, err := os.Stat(oldJournal); err == nil {
+		err = os.Rename(oldJournal, filepath.Join(gs.gameDir(defaultGame), "journal.json"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gs *gameStore) indexFile() string {
+	return filepath.Join(gs.dir, "games.json")
+}
+
+func (gs *gameStore) writeIndex() error {
+	b, err := json.MarshalIndent(&gameIndex{Version: stateVersion, Current: gs.current}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(gs.indexFile(), b)
+}
+
+func (gs *gameStore) gameDir(name string) string {
+	return filepath.Join(gs.dir, name)
+}
+
+func (gs *gameStore) currentGame() string {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.current
+}
+
+func (gs *gameStore) stateFile() string {
+	return filepath.Join(gs.gameDir(gs.currentGame()), "state.json")
+}
+
+func (gs *gameStore) journalFile() string {
+	return filepath.Join(gs.gameDir(gs.currentGame()), "journal.json")
+}
+
+func (gs *gameStore) exists(name string) bool {
+	st, err := os.Stat(gs.gameDir(name))
+	return err == nil && st.IsDir()
+}
+
+func (gs *gameStore) create(name string) error {
+	if err := checkGameName(name); err != nil {
+		return err
+	}
+	if gs.exists(name) {
+		return fmt.Errorf("game %s already exists", name)
+	}
+	return os.MkdirAll(gs.gameDir(name), 0o755)
+}
+
+func (gs *gameStore) switchTo(name string) error {
+	if err := checkGameName(name); err != nil {
+		return err
+	}
+	if !gs.exists(name) {
+		return fmt.Errorf("no game %s", name)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.current = name
+	return gs.writeIndex()
+}
+
+func (gs *gameStore) remove(name string) error {
+	if err := checkGameName(name); err != nil {
+		return err
+	}
+	if name == gs.currentGame() {
+		return fmt.Errorf("can't delete %s, it's the current game", name)
+	}
+	if !gs.exists(name) {
+		return fmt.Errorf("no game %s", name)
+	}
+	return os.RemoveAll(gs.gameDir(name))
+}
+
+func (gs *gameStore) list(ctx context.Context) ([]interface{}, error) {
+	entries, err := os.ReadDir(gs.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() && checkGameName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	games := []interface{}{}
+	for _, name := range names {
+		fn := filepath.Join(gs.gameDir(name), "state.json")
+		theState, err := readState(ctx, fn)
+		if err != nil {
+			games = append(games, map[string]interface{}{"name": name, "error": err.Error()})
+			continue
+		}
+		g := map[string]interface{}{
+			"name":  name,
+			"fen":   theState.game.FEN(),
+			"moves": len(theState.moves),
+		}
+		if st, err := os.Stat(fn); err == nil {
+			g["updated"] = st.ModTime().Format(time.RFC3339)
+		}
+		if j, err := readJournal(filepath.Join(gs.gameDir(name), "journal.json")); err == nil && j != nil {
+			g["move_in_progress"] = j.Move
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+// readState reads a game, a missing file is a new game.
+func readState(ctx context.Context, fn string) (*state, error) {
+	_, span := trace.StartSpan(ctx, "readState")
+	defer span.End()
+
+	data, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return &state{chess.NewGame(), []int{}, nil}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading fen (%s) %w", fn, err)
+	}
+
+	ss := savedState{}
+	err = json.Unmarshal(data, &ss)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal json (%s): %w", fn, err)
+	}
+	if ss.Version > stateVersion {
+		return nil, fmt.Errorf("%s is version %d, this only knows up to %d", fn, ss.Version, stateVersion)
+	}
+
+	f, err := chess.FEN(ss.FEN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fen from (%s) (%s) %w", fn, data, err)
+	}
+	return &state{chess.NewGame(f), ss.Graveyard, ss.Moves}, nil
+}
+
+func writeState(fn string, theState *state) error {
+	ss := savedState{
+		Version:   stateVersion,
+		FEN:       theState.game.FEN(),
+		Graveyard: theState.graveyard,
+		Moves:     theState.moves,
+	}
+	b, err := json.MarshalIndent(&ss, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fn, b)
+}
+
+// doGames manages the saved games. Switching stops play and exhibition mode, they'd be playing the wrong game.
+func (s *viamChessChess) doGames(ctx context.Context, cmd GamesCmd) (map[string]interface{}, error) {
+	if cmd.Switch != "" || cmd.Delete != "" {
+		s.stopPlay()
+		s.stopExhibition()
+	}
+
+	s.doCommandLock.Lock()
+	defer s.doCommandLock.Unlock()
+
+	if cmd.Create != "" {
+		err := s.games.create(cmd.Create)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.Switch != "" && cmd.Switch != s.games.currentGame() {
+		err := s.games.switchTo(cmd.Switch)
+		if err != nil {
+			return nil, err
+		}
+		s.clock.reset()
+		s.logger.Infof("switched to game %s", cmd.Switch)
+	}
+
+	if cmd.Delete != "" {
+		err := s.games.remove(cmd.Delete)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	games, err := s.games.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"current": s.games.currentGame(),
+		"games":   games,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package viamchess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func testGames(t *testing.T) *gameStore {
+	t.Helper()
+	gs, err := openGameStore(context.Background(), t.TempDir(), logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	return gs
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "x.json")
+	test.That(t, writeFileAtomic(fn, []byte("one")), test.ShouldBeNil)
+	test.That(t, writeFileAtomic(fn, []byte("two")), test.ShouldBeNil)
+
+	data, err := os.ReadFile(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldEqual, "two")
+
+	entries, err := os.ReadDir(filepath.Dir(fn))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, entries, test.ShouldHaveLength, 1)
+}
+
+func TestGameStoreMigrate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir() + "/"
+
+	// the old format, no version and right in the data dir
+	old := `{"fen": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "graveyard": [1]}`
+	test.That(t, os.WriteFile(dir+"state.json", []byte(old), 0o666), test.ShouldBeNil)
+
+	gs, err := openGameStore(ctx, dir, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, gs.currentGame(), test.ShouldEqual, defaultGame)
+
+	_, err = os.Stat(dir + "state.json")
+	test.That(t, os.IsNotExist(err), test.ShouldBeTrue)
+
+	theState, err := readState(ctx, gs.stateFile())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, theState.game.FEN(), test.ShouldEqual, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	test.That(t, theState.graveyard, test.ShouldResemble, []int{1})
+
+	data, err := os.ReadFile(gs.stateFile())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldContainSubstring, `"version": 1`)
+
+	// too new
+	test.That(t, os.WriteFile(gs.stateFile(), []byte(`{"version": 99, "fen": ""}`), 0o666), test.ShouldBeNil)
+	_, err = readState(ctx, gs.stateFile())
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestGameStoreGames(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	gs, err := openGameStore(ctx, dir, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	test.That(t, gs.create("demo-2"), test.ShouldBeNil)
+	test.That(t, gs.create("demo-2"), test.ShouldNotBeNil)
+	test.That(t, gs.create("../evil"), test.ShouldNotBeNil)
+	test.That(t, gs.switchTo("nope"), test.ShouldNotBeNil)
+
+	test.That(t, gs.switchTo("demo-2"), test.ShouldBeNil)
+	test.That(t, gs.stateFile(), test.ShouldEqual, filepath.Join(dir, "games", "demo-2", "state.json"))
+	test.That(t, gs.remove("demo-2"), test.ShouldNotBeNil)
+
+	games, err := gs.list(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, games, test.ShouldHaveLength, 2)
+	test.That(t, games[0].(map[string]interface{})["name"], test.ShouldEqual, defaultGame)
+
+	// remembered across restarts
+	gs, err = openGameStore(ctx, dir, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, gs.currentGame(), test.ShouldEqual, "demo-2")
+
+	test.That(t, gs.remove(defaultGame), test.ShouldBeNil)
+	games, err = gs.list(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, games, test.ShouldHaveLength, 1)
+}
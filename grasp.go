@@ -0,0 +1,298 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/utils/trace"
+)
+
+const (
+	minGraspZ             = 12.0 // any lower and the fingers hit the board
+	graspStepZ            = 10.0 // how much lower to go when the gripper closed on nothing
+	graspRecenter         = 6.0  // mm sideways when the gripper closed on something the wrong width
+	maxGraspAttempts      = 8
+	emptyGripWidth        = 20.0 // gripper_position when it closed on nothing
+	defaultGraspTolerance = 40.0
+	defaultOpenWidth      = 450.0 // move_gripper before grabbing
+)
+
+// GraspProfile is how to pick up one kind of piece. Anything left at 0 is the default.
+type GraspProfile struct {
+	OpenWidth    float64 `json:"open-width"`    // move_gripper to open to before grabbing and to let go
+	HeightOffset float64 `json:"height-offset"` // mm added to the grab height, negative to go lower
+	Speed        float64 `json:"speed"`         // joint degs/sec coming down onto the piece, instead of the motion config's
+	Force        float64 `json:"force"`         // passed to the gripper's Grab as force
+}
+
+func (gp GraspProfile) Validate(path string) error {
+	if gp.OpenWidth < 0 || gp.Speed < 0 || gp.Force < 0 {
+		return fmt.Errorf("%s: open-width, speed and force can't be negative", path)
+	}
+	return nil
+}
+
+// graspProfile is the profile for a piece, by letter: p n b r q k, with the defaults filled in.
+func (cfg *ChessConfig) graspProfile(pc chess.Piece) GraspProfile {
+	gp := GraspProfile{}
+	if pc != chess.NoPiece {
+		gp = cfg.GraspProfiles[pc.Type().String()]
+	}
+	if gp.OpenWidth <= 0 {
+		gp.OpenWidth = defaultOpenWidth
+	}
+	return gp
+}
+
+// grabHeight is where to close the gripper for a profile, never low enough to hit the board.
+func (gp GraspProfile) grabHeight(z float64) float64 {
+	return math.Max(z+gp.HeightOffset, minGraspZ)
+}
+
+type graspResult int
+
+const (
+	graspOK      graspResult = iota
+	graspEmpty               // closed on nothing, go lower
+	graspOff                 // closed on something, but not the width of the piece, move over
+	graspDropped             // fell back on the way up, go again where it landed
+)
+
+func (r graspResult) String() string {
+	switch r {
+	case graspOK:
+		return "ok"
+	case graspEmpty:
+		return "empty"
+	case graspDropped:
+		return "dropped"
+	}
+	return "off center"
+}
+
+// recenterNudges are the directions tried, in turn, when a grasp is off center.
+var recenterNudges = [][2]float64{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// classifyGrip says what a closed gripper is holding, given what it's meant to be holding.
+// expected is the gripper position for the piece, 0 if we don't know it.
+func classifyGrip(got bool, width, expected, tolerance float64) graspResult {
+	if !got || width < emptyGripWidth {
+		return graspEmpty
+	}
+	if expected > 0 && (width < expected-tolerance || width > expected+tolerance) {
+		return graspOff
+	}
+	return graspOK
+}
+
+func (cfg *ChessConfig) graspTolerance() float64 {
+	if cfg.GraspTolerance <= 0 {
+		return defaultGraspTolerance
+	}
+	return cfg.GraspTolerance
+}
+
+// expectedWidth is the configured gripper position for a piece, 0 if there isn't one.
+func (cfg *ChessConfig) expectedWidth(pc chess.Piece) float64 {
+	if pc == chess.NoPiece {
+		return 0
+	}
+	return cfg.GraspWidths[pc.Type().String()]
+}
+
+// pieceAt is what the game says is at a square or graveyard slot, NoPiece if we don't know.
+func pieceAt(theState *state, pos string) chess.Piece {
+	if theState == nil {
+		return chess.NoPiece
+	}
+	if sq, ok := parseSquare(pos); ok {
+		if theState.game == nil {
+			return chess.NoPiece
+		}
+		return theState.game.Position().Board().Piece(sq)
+	}
+	if strings.HasPrefix(pos, "X") {
+		x := -1
+		_, err := fmt.Sscanf(pos, "X%d", &x)
+		if err == nil && x >= 0 && x < len(theState.graveyard) {
+			return chess.Piece(theState.graveyard[x])
+		}
+	}
+	return chess.NoPiece
+}
+
+// grip closes the gripper and says what it got. When the gripper can't say how wide it closed,
+// off center can't be told apart from a good grip, only holding something from not.
+func (s *viamChessChess) grip(ctx context.Context, pc chess.Piece, gp GraspProfile) (graspResult, error) {
+	var extra map[string]interface{}
+	if gp.Force > 0 {
+		extra = map[string]interface{}{"force": gp.Force}
+	}
+	got, err := s.hand.grab(ctx, extra)
+	if err != nil {
+		return graspEmpty, err
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	held, width, err := s.hand.holding(ctx)
+	if err != nil {
+		return graspEmpty, err
+	}
+
+	res := graspEmpty
+	switch {
+	case width > 0:
+		res = classifyGrip(got, width, s.conf.expectedWidth(pc), s.conf.graspTolerance())
+	case got && held:
+		res = graspOK
+	}
+	s.logger.Debugf("grip on %v: got %v held %v width %v -> %v", pc, got, held, width, res)
+	return res, nil
+}
+
+// approach comes down onto a piece at the profile's speed. Without fromAbove it's a small straight
+// move from where the last try was.
+func (s *viamChessChess) approach(ctx context.Context, at r3.Vector, gp GraspProfile, ws *referenceframe.WorldState, fromAbove bool) error {
+	path := []waypoint{{p: at, straight: true}}
+	if fromAbove {
+		path = s.conf.Motion.downWaypoints(at)
+	}
+	for i := range path {
+		path[i].speed = gp.Speed
+	}
+	return s.moveAlong(ctx, path, ws)
+}
+
+// grasp picks up the piece at from and lifts it to the safe height. When the gripper closes on nothing it goes a bit lower,
+// when it closes on something the wrong width it moves over a bit. With verify-grasp it also goes
+// back to look and make sure the square is empty. How it opens, how low it goes, how fast and how hard it
+// grabs come from the piece's grasp profile, pc is the piece, NoPiece if we don't know. Returns the height it grabbed at.
+func (s *viamChessChess) grasp(ctx context.Context, pc chess.Piece, from string, center r3.Vector, ws *referenceframe.WorldState) (float64, error) {
+	ctx, span := trace.StartSpan(ctx, "grasp")
+	defer span.End()
+
+	gp := s.conf.graspProfile(pc)
+	at := center
+	at.Z = gp.grabHeight(center.Z)
+	nudge := 0
+	above := false // if we're up at the safe height, or further, and need to come down from above
+
+	for attempt := 1; ; attempt++ {
+		err := s.hand.prepare(ctx, gp.OpenWidth)
+		if err != nil {
+			return 0, err
+		}
+
+		fromAbove := attempt == 1 || above
+		if fromAbove {
+			err = s.moveGripper(ctx, r3.Vector{X: at.X, Y: at.Y, Z: s.safeZ()}, ws)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		err = s.approach(ctx, at, gp, ws, fromAbove)
+		if err != nil {
+			return 0, err
+		}
+
+		res, err := s.grip(ctx, pc, gp)
+		if err != nil {
+			return 0, err
+		}
+
+		if res == graspOK {
+			err = s.goUp(ctx, at, ws)
+			if err != nil {
+				return 0, err
+			}
+
+			lifted, seen, err := s.verifyLifted(ctx, from)
+			if err != nil {
+				return 0, err
+			}
+			if lifted {
+				return at.Z, nil
+			}
+			res = graspDropped
+			center = seen
+			above = true
+		}
+
+		if attempt >= maxGraspAttempts {
+			return 0, fmt.Errorf("couldn't grab %s after %d tries", from, attempt)
+		}
+
+		switch res {
+		case graspEmpty:
+			at.Z -= graspStepZ
+			if at.Z < minGraspZ {
+				return 0, fmt.Errorf("couldn't grab %s, and scared to go lower", from)
+			}
+		case graspOff:
+			n := recenterNudges[nudge%len(recenterNudges)]
+			nudge++
+			at.X = center.X + n[0]*graspRecenter
+			at.Y = center.Y + n[1]*graspRecenter
+		case graspDropped:
+			// the grip was fine, the piece is just somewhere else now
+			at = center
+			at.Z = gp.grabHeight(center.Z)
+		}
+		s.logger.Warnf("grab on %s was %v, trying again at %v", from, res, at)
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// verifyLifted goes back to look at the board, still holding the piece, and checks from is empty now.
+// If it fell back, it also says where the piece is now, from what it just saw.
+// The graveyard is off camera, so that's taken on trust, as is everything without verify-grasp.
+func (s *viamChessChess) verifyLifted(ctx context.Context, from string) (bool, r3.Vector, error) {
+	sq, ok := parseSquare(from)
+	if !s.conf.VerifyGrasp || !ok {
+		return true, r3.Vector{}, nil
+	}
+
+	err := s.goToStartHolding(ctx)
+	if err != nil {
+		return false, r3.Vector{}, err
+	}
+
+	all, err := s.capture(ctx)
+	if err != nil {
+		return false, r3.Vector{}, err
+	}
+
+	colors, err := s.boardColors(all)
+	if err != nil {
+		return false, r3.Vector{}, err
+	}
+	if colors[sq] == 0 {
+		return true, r3.Vector{}, nil
+	}
+
+	// still there: fine if it slipped out, but if we're holding something it came from somewhere else
+	held, _, err := s.hand.holding(ctx)
+	if err != nil {
+		return false, r3.Vector{}, err
+	}
+	if held {
+		return false, r3.Vector{}, fmt.Errorf("holding something, but %s still has a piece on it", from)
+	}
+	s.logger.Warnf("%s dropped on the way up", from)
+
+	center, err := s.getCenterFor(all, from, nil)
+	if err != nil {
+		return false, r3.Vector{}, err
+	}
+	return false, center, nil
+}
@@ -0,0 +1,95 @@
+package viamchess
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestClassifyGrip(t *testing.T) {
+	test.That(t, classifyGrip(false, 200, 0, 40), test.ShouldEqual, graspEmpty)
+	test.That(t, classifyGrip(true, 10, 0, 40), test.ShouldEqual, graspEmpty)
+	test.That(t, classifyGrip(true, 200, 0, 40), test.ShouldEqual, graspOK)
+	test.That(t, classifyGrip(true, 200, 220, 40), test.ShouldEqual, graspOK)
+	test.That(t, classifyGrip(true, 120, 220, 40), test.ShouldEqual, graspOff)
+	test.That(t, classifyGrip(true, 300, 220, 40), test.ShouldEqual, graspOff)
+}
+
+func TestExpectedWidth(t *testing.T) {
+	cfg := &ChessConfig{GraspWidths: map[string]float64{"p": 180, "k": 260}}
+	test.That(t, cfg.expectedWidth(chess.WhitePawn), test.ShouldEqual, 180.0)
+	test.That(t, cfg.expectedWidth(chess.BlackKing), test.ShouldEqual, 260.0)
+	test.That(t, cfg.expectedWidth(chess.WhiteQueen), test.ShouldEqual, 0.0)
+	test.That(t, cfg.expectedWidth(chess.NoPiece), test.ShouldEqual, 0.0)
+	test.That(t, cfg.graspTolerance(), test.ShouldEqual, defaultGraspTolerance)
+}
+
+func TestPieceAt(t *testing.T) {
+	theState := &state{chess.NewGame(), []int{int(chess.BlackKnight)}, nil}
+	test.That(t, pieceAt(theState, "e2"), test.ShouldEqual, chess.WhitePawn)
+	test.That(t, pieceAt(theState, "e4"), test.ShouldEqual, chess.NoPiece)
+	test.That(t, pieceAt(theState, "X0"), test.ShouldEqual, chess.BlackKnight)
+	test.That(t, pieceAt(theState, "X1"), test.ShouldEqual, chess.NoPiece)
+	test.That(t, pieceAt(nil, "e2"), test.ShouldEqual, chess.NoPiece)
+
+	// outside a game only the graveyard is known
+	around := graveyardOnly([]int{int(chess.BlackKnight)})
+	test.That(t, pieceAt(around, "e2"), test.ShouldEqual, chess.NoPiece)
+	test.That(t, pieceAt(around, "X0"), test.ShouldEqual, chess.BlackKnight)
+}
+
+func TestGraspProfile(t *testing.T) {
+	cfg := &ChessConfig{GraspProfiles: map[string]GraspProfile{
+		"n": {OpenWidth: 600, HeightOffset: -15, Speed: 20, Force: 300},
+		"k": {HeightOffset: 10},
+	}}
+
+	gp := cfg.graspProfile(chess.BlackKnight)
+	test.That(t, gp.OpenWidth, test.ShouldEqual, 600.0)
+	test.That(t, gp.Speed, test.ShouldEqual, 20.0)
+	test.That(t, gp.Force, test.ShouldEqual, 300.0)
+	test.That(t, gp.grabHeight(50), test.ShouldEqual, 35.0)
+	test.That(t, gp.grabHeight(20), test.ShouldEqual, minGraspZ)
+
+	gp = cfg.graspProfile(chess.WhiteKing)
+	test.That(t, gp.OpenWidth, test.ShouldEqual, defaultOpenWidth)
+	test.That(t, gp.grabHeight(50), test.ShouldEqual, 60.0)
+
+	test.That(t, cfg.graspProfile(chess.WhitePawn), test.ShouldResemble, GraspProfile{OpenWidth: defaultOpenWidth})
+	test.That(t, cfg.graspProfile(chess.NoPiece), test.ShouldResemble, GraspProfile{OpenWidth: defaultOpenWidth})
+
+	test.That(t, GraspProfile{Speed: -1}.Validate("x"), test.ShouldNotBeNil)
+	test.That(t, GraspProfile{HeightOffset: -10}.Validate("x"), test.ShouldBeNil)
+}
+
+func TestApproachSpeed(t *testing.T) {
+	// recordingArm has no DoCommand, so nothing global gets changed on the arm
+	a := &recordingArm{}
+	s := &viamChessChess{
+		logger:    logging.NewTestLogger(t),
+		conf:      &ChessConfig{Arm: "arm", Gripper: "gripper", Motion: &MotionConfig{ApproachHeight: 40, JointSpeed: 90}},
+		arm:       a,
+		motion:    &planningMotion{},
+		startPose: referenceframe.NewPoseInFrame("world", spatialmath.NewZeroPose()),
+	}
+
+	err := s.approach(context.Background(), r3.Vector{X: 100, Z: 30}, GraspProfile{Speed: 30}, nil, true)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(a.opts), test.ShouldEqual, 2)
+	for _, o := range a.opts {
+		test.That(t, o.MaxVelRads, test.ShouldAlmostEqual, math.Pi/6)
+	}
+
+	// everything else goes at joint-speed
+	err = s.moveGripper(context.Background(), r3.Vector{X: 100, Z: 200}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, a.opts[2].MaxVelRads, test.ShouldAlmostEqual, math.Pi/2)
+}
@@ -0,0 +1,121 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gripper"
+)
+
+const (
+	gripperXArm     = "xarm"     // gripper built into the arm, driven with the arm's move_gripper and get_gripper
+	gripperStandard = "standard" // anything with the gripper api: Open, Grab, IsHoldingSomething
+	gripperSuction  = "suction"  // vacuum cup behind the gripper api: Grab sucks, Open lets go
+
+	suctionVent = 300 * time.Millisecond // time for the vacuum to let go of the piece
+)
+
+// gripperAdapter is what we need from an end effector to pick up and put down pieces.
+type gripperAdapter interface {
+	// prepare lets go and gets ready to grab, opening to open where the gripper can do that
+	prepare(ctx context.Context, open float64) error
+	// release lets go of whatever it's holding
+	release(ctx context.Context) error
+	grab(ctx context.Context, extra map[string]interface{}) (bool, error)
+	// holding says if it has something, and how wide it's closed, 0 if it can't tell
+	holding(ctx context.Context) (bool, float64, error)
+}
+
+func newGripperAdapter(kind string, a arm.Arm, g gripper.Gripper) (gripperAdapter, error) {
+	switch kind {
+	case "", gripperXArm:
+		return &xarmGripper{a, g}, nil
+	case gripperStandard:
+		return &standardGripper{g}, nil
+	case gripperSuction:
+		return &suctionGripper{g}, nil
+	}
+	return nil, fmt.Errorf("bad gripper-type (%s), need xarm, standard or suction", kind)
+}
+
+type xarmGripper struct {
+	arm     arm.Arm
+	gripper gripper.Gripper
+}
+
+func (x *xarmGripper) prepare(ctx context.Context, open float64) error {
+	_, err := x.arm.DoCommand(ctx, map[string]interface{}{"move_gripper": open})
+	return err
+}
+
+func (x *xarmGripper) release(ctx context.Context) error {
+	return x.gripper.Open(ctx, nil)
+}
+
+func (x *xarmGripper) grab(ctx context.Context, extra map[string]interface{}) (bool, error) {
+	return x.gripper.Grab(ctx, extra)
+}
+
+func (x *xarmGripper) holding(ctx context.Context) (bool, float64, error) {
+	res, err := x.arm.DoCommand(ctx, map[string]interface{}{"get_gripper": true})
+	if err != nil {
+		return false, 0, err
+	}
+
+	p, ok := res["gripper_position"].(float64)
+	if !ok {
+		return false, 0, fmt.Errorf("Why is get_gripper weird %v", res)
+	}
+	return p >= emptyGripWidth, p, nil
+}
+
+type standardGripper struct {
+	gripper gripper.Gripper
+}
+
+func (sg *standardGripper) prepare(ctx context.Context, open float64) error {
+	return sg.gripper.Open(ctx, nil)
+}
+
+func (sg *standardGripper) release(ctx context.Context) error {
+	return sg.gripper.Open(ctx, nil)
+}
+
+func (sg *standardGripper) grab(ctx context.Context, extra map[string]interface{}) (bool, error) {
+	return sg.gripper.Grab(ctx, extra)
+}
+
+func (sg *standardGripper) holding(ctx context.Context) (bool, float64, error) {
+	hs, err := sg.gripper.IsHoldingSomething(ctx, nil)
+	return hs.IsHoldingSomething, 0, err
+}
+
+// suctionGripper has nothing to open, and what Grab returns means little, so holding is all we go by.
+type suctionGripper struct {
+	gripper gripper.Gripper
+}
+
+func (sc *suctionGripper) prepare(ctx context.Context, open float64) error {
+	return sc.release(ctx)
+}
+
+func (sc *suctionGripper) release(ctx context.Context) error {
+	err := sc.gripper.Open(ctx, nil)
+	if err != nil {
+		return err
+	}
+	time.Sleep(suctionVent)
+	return nil
+}
+
+func (sc *suctionGripper) grab(ctx context.Context, extra map[string]interface{}) (bool, error) {
+	_, err := sc.gripper.Grab(ctx, extra)
+	return true, err
+}
+
+func (sc *suctionGripper) holding(ctx context.Context) (bool, float64, error) {
+	hs, err := sc.gripper.IsHoldingSomething(ctx, nil)
+	return hs.IsHoldingSomething, 0, err
+}
@@ -0,0 +1,83 @@
+package viamchess
+
+import (
+	"context"
+	"testing"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/components/gripper"
+	"go.viam.com/test"
+)
+
+// fakeArm and fakeGripper only have what the adapters use, anything else panics.
+type fakeArm struct {
+	arm.Arm
+	cmds []map[string]interface{}
+}
+
+func (a *fakeArm) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	a.cmds = append(a.cmds, cmd)
+	return map[string]interface{}{"gripper_position": 210.0}, nil
+}
+
+type fakeGripper struct {
+	gripper.Gripper
+	opens, grabs int
+}
+
+func (g *fakeGripper) Open(ctx context.Context, extra map[string]interface{}) error {
+	g.opens++
+	return nil
+}
+
+func (g *fakeGripper) Grab(ctx context.Context, extra map[string]interface{}) (bool, error) {
+	g.grabs++
+	return false, nil
+}
+
+func (g *fakeGripper) IsHoldingSomething(ctx context.Context, extra map[string]interface{}) (gripper.HoldingStatus, error) {
+	return gripper.HoldingStatus{IsHoldingSomething: true}, nil
+}
+
+func TestGripperAdapters(t *testing.T) {
+	ctx := context.Background()
+
+	a := &fakeArm{}
+	g := &fakeGripper{}
+
+	// xarm goes through the arm
+	h, err := newGripperAdapter("", a, g)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, h.prepare(ctx, 500), test.ShouldBeNil)
+	test.That(t, a.cmds[0]["move_gripper"], test.ShouldEqual, 500.0)
+	held, width, err := h.holding(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, held, test.ShouldBeTrue)
+	test.That(t, width, test.ShouldEqual, 210.0)
+	test.That(t, g.opens, test.ShouldEqual, 0)
+
+	// standard only knows open
+	h, err = newGripperAdapter(gripperStandard, a, g)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, h.prepare(ctx, 500), test.ShouldBeNil)
+	test.That(t, g.opens, test.ShouldEqual, 1)
+	held, width, err = h.holding(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, held, test.ShouldBeTrue)
+	test.That(t, width, test.ShouldEqual, 0.0)
+
+	// suction doesn't trust Grab
+	h, err = newGripperAdapter(gripperSuction, a, g)
+	test.That(t, err, test.ShouldBeNil)
+	got, err := h.grab(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, got, test.ShouldBeTrue)
+	test.That(t, g.grabs, test.ShouldEqual, 1)
+	test.That(t, h.release(ctx), test.ShouldBeNil)
+	test.That(t, g.opens, test.ShouldEqual, 2)
+
+	test.That(t, len(a.cmds), test.ShouldEqual, 2)
+
+	_, err = newGripperAdapter("claw", a, g)
+	test.That(t, err, test.ShouldNotBeNil)
+}
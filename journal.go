@@ -0,0 +1,348 @@
+package viamchess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/vision/viscapture"
+)
+
+const (
+	recoverFinish   = "finish"
+	recoverRollback = "rollback"
+	recoverCheck    = "check"
+)
+
+// journalStep is one piece picked up and put down. To is a square, or X<n> for a graveyard slot.
+type journalStep struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Piece int    `json:"piece"` // what's being moved, so the graveyard knows what it got
+}
+
+// moveJournal is a robot move in progress. It's written before the arm moves and after every step,
+// so a move cut off by an error or a restart can be finished or undone instead of
+// leaving the board and the saved state disagreeing.
+type moveJournal struct {
+	FEN   string        `json:"fen"` // the position before the move
+	Move  string        `json:"move"`
+	Steps []journalStep `json:"steps"`
+	Done  int           `json:"done"` // steps we know are done
+}
+
+func (j *moveJournal) toMap() map[string]interface{} {
+	steps := []interface{}{}
+	for _, st := range j.Steps {
+		steps = append(steps, st.From+"-"+st.To)
+	}
+	return map[string]interface{}{
+		"fen":   j.FEN,
+		"move":  j.Move,
+		"steps": steps,
+		"done":  j.Done,
+	}
+}
+
+// readJournal returns nil if there's no move in progress.
+func readJournal(fn string) (*moveJournal, error) {
+	data, err := os.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read journal (%s): %w", fn, err)
+	}
+
+	j := &moveJournal{}
+	err = json.Unmarshal(data, j)
+	if err != nil {
+		return nil, fmt.Errorf("bad journal (%s): %w", fn, err)
+	}
+	return j, nil
+}
+
+func writeJournal(fn string, j *moveJournal) error {
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fn, b)
+}
+
+func removeJournal(fn string) error {
+	err := os.Remove(fn)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func castleRook(m *chess.Move) (string, string, error) {
+	switch m.S2() {
+	case chess.G1:
+		return "h1", "f1", nil
+	case chess.C1:
+		return "a1", "d1", nil
+	case chess.G8:
+		return "h8", "f8", nil
+	case chess.C8:
+		return "a8", "d8", nil
+	}
+	return "", "", fmt.Errorf("bad castle? %v", m)
+}
+
+// planSteps breaks a move into transfers: captured piece to the graveyard first, rook before king.
+func planSteps(pos *chess.Position, m *chess.Move, graveyard int) ([]journalStep, error) {
+	if m.HasTag(chess.EnPassant) {
+		return nil, fmt.Errorf("can't handle enpassant")
+	}
+
+	board := pos.Board()
+	steps := []journalStep{}
+
+	if m.HasTag(chess.Capture) {
+		steps = append(steps, journalStep{m.S2().String(), fmt.Sprintf("X%d", graveyard), int(board.Piece(m.S2()))})
+	}
+
+	if m.HasTag(chess.KingSideCastle) || m.HasTag(chess.QueenSideCastle) {
+		f, t, err := castleRook(m)
+		if err != nil {
+			return nil, err
+		}
+		sq, _ := parseSquare(f)
+		steps = append(steps, journalStep{f, t, int(board.Piece(sq))})
+	}
+
+	steps = append(steps, journalStep{m.S1().String(), m.S2().String(), int(board.Piece(m.S1()))})
+	return steps, nil
+}
+
+// legalMove finds the legal move in pos for a uci string.
+func legalMove(pos *chess.Position, move string) (*chess.Move, error) {
+	moves := pos.ValidMoves()
+	for i := range moves {
+		if moves[i].String() == move {
+			return &moves[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s isn't legal in %s", move, pos)
+}
+
+func parseSquare(name string) (chess.Square, bool) {
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if sq.String() == name {
+			return sq, true
+		}
+	}
+	return chess.NoSquare, false
+}
+
+// stepApplied uses what the camera sees to work out if a step got done. The graveyard
+// is off the board, so for that end we go by the other one alone.
+func stepApplied(colors map[chess.Square]int, st journalStep) (bool, error) {
+	from, fromBoard := parseSquare(st.From)
+	to, toBoard := parseSquare(st.To)
+
+	fromEmpty := fromBoard && colors[from] == 0
+	toFull := toBoard && colors[to] != 0
+
+	switch {
+	case fromBoard && toBoard:
+		if fromEmpty && toFull {
+			return true, nil
+		}
+		if !fromEmpty && !toFull {
+			return false, nil
+		}
+		if fromEmpty {
+			return false, fmt.Errorf("the piece from %s isn't on %s either, put it back by hand", st.From, st.To)
+		}
+		return false, fmt.Errorf("there are pieces on both %s and %s", st.From, st.To)
+	case fromBoard:
+		return fromEmpty, nil
+	case toBoard:
+		return toFull, nil
+	}
+	return false, fmt.Errorf("bad step %s-%s", st.From, st.To)
+}
+
+// stepDone keeps the graveyard up with a step as soon as it's done, so the steps after plan around it.
+func stepDone(theState *state, st journalStep) {
+	if strings.HasPrefix(st.To, "X") {
+		theState.graveyard = append(theState.graveyard, st.Piece)
+	}
+}
+
+// runJournal does the steps that are left, then saves the move into the game. The board is looked at
+// again between steps, a captured piece or a castling rook has moved since data was captured.
+func (s *viamChessChess) runJournal(ctx context.Context, data viscapture.VisCapture, theState *state, j *moveJournal) (*chess.Move, error) {
+	m, err := legalMove(theState.game.Position(), j.Move)
+	if err != nil {
+		return nil, err
+	}
+
+	// what the board looks like with the steps done so far, the graveyard only gets saved with the move
+	expect := positionColors(theState.game.Position())
+	for _, st := range j.Steps[:j.Done] {
+		applyStep(expect, st)
+		stepDone(theState, st)
+	}
+
+	fresh := true
+	for j.Done < len(j.Steps) {
+		if !fresh {
+			err := s.goToStart(ctx)
+			if err != nil {
+				return nil, err
+			}
+			data, err = s.capture(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		fresh = false
+
+		st := j.Steps[j.Done]
+		s.logger.Infof("step %d of %s: %s -> %s", j.Done+1, j.Move, st.From, st.To)
+		after := maps.Clone(expect)
+		applyStep(after, st)
+		err := s.transfer(ctx, data, theState, st.From, st.To, chess.Piece(st.Piece), after)
+		if err != nil {
+			return nil, fmt.Errorf("%s stopped at %s -> %s: %w", j.Move, st.From, st.To, err)
+		}
+		expect = after
+		stepDone(theState, st)
+		j.Done++
+		err = writeJournal(s.games.journalFile(), j)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	turn := theState.game.Position().Turn()
+	err = theState.game.Move(m, nil)
+	if err != nil {
+		return nil, err
+	}
+	theState.moves = append(theState.moves, moveRecord{Move: m.String(), Color: turn.Name()})
+
+	err = s.saveGame(ctx, theState)
+	if err != nil {
+		return nil, err
+	}
+	s.clearAlert()
+	return m, removeJournal(s.games.journalFile())
+}
+
+// playMove is how the robot moves: journal it, then do it.
+func (s *viamChessChess) playMove(ctx context.Context, data viscapture.VisCapture, theState *state, m *chess.Move) error {
+	steps, err := planSteps(theState.game.Position(), m, len(theState.graveyard))
+	if err != nil {
+		return err
+	}
+
+	j := &moveJournal{FEN: theState.game.FEN(), Move: m.String(), Steps: steps}
+	err = writeJournal(s.games.journalFile(), j)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.runJournal(ctx, data, theState, j)
+	return err
+}
+
+// recoverMove deals with a move that got cut off. finish does the rest of it, rollback puts back what was done,
+// and check only says where it's at. Returns the move if it got finished.
+func (s *viamChessChess) recoverMove(ctx context.Context, mode string) (*chess.Move, map[string]interface{}, error) {
+	j, err := readJournal(s.games.journalFile())
+	if err != nil {
+		return nil, nil, err
+	}
+	if j == nil {
+		return nil, map[string]interface{}{"recover": "nothing to do"}, nil
+	}
+
+	theState, err := s.getGame(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if j.FEN != theState.game.FEN() {
+		// the move got saved, but we stopped before the journal was removed
+		f, err := chess.FEN(j.FEN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad fen in journal: %w", err)
+		}
+		before := chess.NewGame(f).Position()
+		m, err := legalMove(before, j.Move)
+		if err == nil && before.Update(m).String() == theState.game.FEN() {
+			s.logger.Infof("%s was already saved", j.Move)
+			return nil, map[string]interface{}{"recover": "already done", "move": j.Move}, removeJournal(s.games.journalFile())
+		}
+		return nil, nil, fmt.Errorf("journal for %s doesn't match the game, fix the board and reset", j.Move)
+	}
+
+	all, err := s.capture(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if j.Done < len(j.Steps) {
+		colors, err := s.boardColors(all)
+		if err != nil {
+			return nil, nil, err
+		}
+		applied, err := stepApplied(colors, j.Steps[j.Done])
+		if err != nil {
+			return nil, nil, err
+		}
+		if applied {
+			j.Done++
+			err = writeJournal(s.games.journalFile(), j)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	res := j.toMap()
+	switch mode {
+	case recoverCheck:
+		return nil, res, nil
+	case recoverFinish:
+		s.logger.Infof("finishing %s from step %d", j.Move, j.Done+1)
+		m, err := s.runJournal(ctx, all, theState, j)
+		if err != nil {
+			return nil, nil, err
+		}
+		res["recover"] = "finished"
+		return m, res, nil
+	case recoverRollback:
+		s.logger.Infof("rolling back %s, %d steps done", j.Move, j.Done)
+		for j.Done > 0 {
+			st := j.Steps[j.Done-1]
+			err := s.transfer(ctx, all, theState, st.To, st.From, chess.Piece(st.Piece), nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf("rolling back %s stopped at %s -> %s: %w", j.Move, st.To, st.From, err)
+			}
+			j.Done--
+			err = writeJournal(s.games.journalFile(), j)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		res["recover"] = "rolled back"
+		err = removeJournal(s.games.journalFile())
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, res, s.goToStart(ctx) // out of the camera's way before anyone looks again
+	}
+	return nil, nil, fmt.Errorf("bad recover (%s), need finish, rollback or check", mode)
+}
@@ -0,0 +1,132 @@
+package viamchess
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestPlanSteps(t *testing.T) {
+	for _, tc := range []struct {
+		name, fen, move string
+		steps           []string
+	}{
+		{"plain", chess.StartingPosition().String(), "e2e4", []string{"e2-e4"}},
+		{"capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5", []string{"d5-X3", "e4-d5"}},
+		{"short castle", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", []string{"h1-f1", "e1-g1"}},
+		{"long castle", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", []string{"a8-d8", "e8-c8"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pos := positionFromFEN(t, tc.fen)
+			m, err := legalMove(pos, tc.move)
+			test.That(t, err, test.ShouldBeNil)
+			steps, err := planSteps(pos, m, 3)
+			test.That(t, err, test.ShouldBeNil)
+			got := []string{}
+			for _, st := range steps {
+				got = append(got, st.From+"-"+st.To)
+			}
+			test.That(t, got, test.ShouldResemble, tc.steps)
+		})
+	}
+
+	pos := positionFromFEN(t, "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2")
+	m, err := legalMove(pos, "e4d5")
+	test.That(t, err, test.ShouldBeNil)
+	steps, err := planSteps(pos, m, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, steps[0].Piece, test.ShouldEqual, int(chess.BlackPawn))
+
+	pos = positionFromFEN(t, "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1")
+	m, err = legalMove(pos, "e5d6")
+	test.That(t, err, test.ShouldBeNil)
+	_, err = planSteps(pos, m, 0)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestStepApplied(t *testing.T) {
+	colors := positionColors(chess.StartingPosition())
+
+	applied, err := stepApplied(colors, journalStep{From: "e2", To: "e4"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, applied, test.ShouldBeFalse)
+
+	colors[chess.E2], colors[chess.E4] = 0, 1
+	applied, err = stepApplied(colors, journalStep{From: "e2", To: "e4"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, applied, test.ShouldBeTrue)
+
+	// in the gripper, or on the floor
+	colors[chess.E4] = 0
+	_, err = stepApplied(colors, journalStep{From: "e2", To: "e4"})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	applied, err = stepApplied(colors, journalStep{From: "e2", To: "X0"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, applied, test.ShouldBeTrue)
+	applied, err = stepApplied(colors, journalStep{From: "d2", To: "X0"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, applied, test.ShouldBeFalse)
+}
+
+func TestStepDone(t *testing.T) {
+	theState := &state{chess.NewGame(), []int{int(chess.BlackPawn)}, nil}
+	stepDone(theState, journalStep{"d5", "X1", int(chess.BlackKnight)})
+	stepDone(theState, journalStep{"e4", "d5", int(chess.WhitePawn)})
+	test.That(t, theState.graveyard, test.ShouldResemble, []int{int(chess.BlackPawn), int(chess.BlackKnight)})
+	test.That(t, pieceAt(theState, "X1"), test.ShouldEqual, chess.BlackKnight)
+}
+
+func TestJournalFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "journal.json")
+
+	j, err := readJournal(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, j, test.ShouldBeNil)
+
+	in := &moveJournal{FEN: chess.StartingPosition().String(), Move: "e2e4", Steps: []journalStep{{"e2", "e4", int(chess.WhitePawn)}}, Done: 1}
+	test.That(t, writeJournal(fn, in), test.ShouldBeNil)
+	j, err = readJournal(fn)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, j, test.ShouldResemble, in)
+
+	test.That(t, removeJournal(fn), test.ShouldBeNil)
+	test.That(t, removeJournal(fn), test.ShouldBeNil)
+}
+
+func TestRecoverMoveAlreadySaved(t *testing.T) {
+	s := &viamChessChess{logger: logging.NewTestLogger(t), games: testGames(t)}
+	ctx := context.Background()
+
+	_, res, err := s.recoverMove(ctx, recoverFinish)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res["recover"], test.ShouldEqual, "nothing to do")
+
+	// the move was saved, but not the journal's removal
+	theState, err := s.getGame(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	j := &moveJournal{FEN: theState.game.FEN(), Move: "e2e4", Steps: []journalStep{{"e2", "e4", int(chess.WhitePawn)}}, Done: 1}
+	test.That(t, writeJournal(s.games.journalFile(), j), test.ShouldBeNil)
+	m, err := legalMove(theState.game.Position(), "e2e4")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, theState.game.Move(m, nil), test.ShouldBeNil)
+	test.That(t, s.saveGame(ctx, theState), test.ShouldBeNil)
+
+	_, res, err = s.recoverMove(ctx, recoverFinish)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, res["recover"], test.ShouldEqual, "already done")
+	j, err = readJournal(s.games.journalFile())
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, j, test.ShouldBeNil)
+
+	// a journal from some other game
+	j = &moveJournal{FEN: chess.StartingPosition().String(), Move: "d2d4"}
+	test.That(t, writeJournal(s.games.journalFile(), j), test.ShouldBeNil)
+	_, _, err = s.recoverMove(ctx, recoverFinish)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,134 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/referenceframe"
+)
+
+// MotionConfig is how the arm gets around. Anything left at 0 is the default, which is how it always moved:
+// up to safe-height, over, and straight down, with no constraints.
+type MotionConfig struct {
+	SafeHeight     float64 `json:"safe-height"`     // mm, travel height between squares
+	ApproachHeight float64 `json:"approach-height"` // mm above a grab or drop to go to freely, before coming straight down
+	RetreatHeight  float64 `json:"retreat-height"`  // mm above a grab or drop to go straight up to, before moving freely
+
+	// there's no mm/s limit, neither the plan request nor the arm's move options have one
+	JointSpeed        float64 `json:"joint-speed"`        // degs/sec, top speed of every joint, passed to the arm with each move
+	JointAcceleration float64 `json:"joint-acceleration"` // degs/sec^2, passed to the arm with each move
+
+	LineTolerance        float64 `json:"line-tolerance"`        // mm off a straight line going up and down, 0 for no constraint
+	OrientationTolerance float64 `json:"orientation-tolerance"` // degs, with line-tolerance
+
+	CacheJoints bool `json:"cache-joints"` // plan each spot once and go straight to its joints after
+}
+
+func (mc *MotionConfig) Validate(path string) error {
+	if mc.SafeHeight < 0 || mc.ApproachHeight < 0 || mc.RetreatHeight < 0 {
+		return fmt.Errorf("%s: heights can't be negative", path)
+	}
+	if mc.JointSpeed < 0 || mc.JointAcceleration < 0 {
+		return fmt.Errorf("%s: joint-speed and joint-acceleration can't be negative", path)
+	}
+	if mc.LineTolerance < 0 || mc.OrientationTolerance < 0 {
+		return fmt.Errorf("%s: tolerances can't be negative", path)
+	}
+	return nil
+}
+
+func (mc *MotionConfig) safeHeight() float64 {
+	if mc == nil || mc.SafeHeight <= 0 {
+		return defaultSafeZ
+	}
+	return mc.SafeHeight
+}
+
+// moveOptions is what goes to the arm with every move, nil to leave it at the arm's own speed.
+// speed, in degs/sec, is used instead of joint-speed if it's set.
+func (mc *MotionConfig) moveOptions(speed float64) *arm.MoveOptions {
+	acc := 0.0
+	if mc != nil {
+		acc = mc.JointAcceleration
+		if speed <= 0 {
+			speed = mc.JointSpeed
+		}
+	}
+	if speed <= 0 && acc <= 0 {
+		return nil
+	}
+	return &arm.MoveOptions{
+		MaxVelRads: speed * math.Pi / 180,
+		MaxAccRads: acc * math.Pi / 180,
+	}
+}
+
+// linear is the constraint for going straight up and down, nil without a line-tolerance.
+func (mc *MotionConfig) linear() *motionplan.Constraints {
+	if mc == nil || mc.LineTolerance <= 0 {
+		return nil
+	}
+	return &motionplan.Constraints{
+		LinearConstraint: []motionplan.LinearConstraint{
+			{LineToleranceMm: mc.LineTolerance, OrientationToleranceDegs: mc.OrientationTolerance},
+		},
+	}
+}
+
+// downPath is where to go, in order, to come down to at from above. Only the last one is straight.
+func (mc *MotionConfig) downPath(at r3.Vector) []r3.Vector {
+	path := []r3.Vector{}
+	if mc != nil && mc.ApproachHeight > 0 && at.Z+mc.ApproachHeight < mc.safeHeight() {
+		path = append(path, r3.Vector{X: at.X, Y: at.Y, Z: at.Z + mc.ApproachHeight})
+	}
+	return append(path, at)
+}
+
+// upPath is where to go, in order, to get from at up to the safe height. Only the first one is straight.
+func (mc *MotionConfig) upPath(at r3.Vector) []r3.Vector {
+	path := []r3.Vector{}
+	if mc != nil && mc.RetreatHeight > 0 && at.Z+mc.RetreatHeight < mc.safeHeight() {
+		path = append(path, r3.Vector{X: at.X, Y: at.Y, Z: at.Z + mc.RetreatHeight})
+	}
+	return append(path, r3.Vector{X: at.X, Y: at.Y, Z: mc.safeHeight()})
+}
+
+// safeZ is how high to travel between squares.
+func (s *viamChessChess) safeZ() float64 {
+	return s.conf.Motion.safeHeight()
+}
+
+// downWaypoints is downPath, straight for the last bit.
+func (mc *MotionConfig) downWaypoints(at r3.Vector) []waypoint {
+	path := mc.downPath(at)
+	out := []waypoint{}
+	for i, p := range path {
+		out = append(out, waypoint{p: p, straight: i == len(path)-1})
+	}
+	return out
+}
+
+// comeDown goes down to at from above it, straight down for the last bit.
+func (s *viamChessChess) comeDown(ctx context.Context, at r3.Vector, ws *referenceframe.WorldState) error {
+	return s.moveAlong(ctx, s.conf.Motion.downWaypoints(at), ws)
+}
+
+// travelDown goes over to above at, at the safe height, and comes down to it.
+func (s *viamChessChess) travelDown(ctx context.Context, at r3.Vector, ws *referenceframe.WorldState) error {
+	path := []waypoint{{p: r3.Vector{X: at.X, Y: at.Y, Z: s.safeZ()}}}
+	return s.moveAlong(ctx, append(path, s.conf.Motion.downWaypoints(at)...), ws)
+}
+
+// goUp goes from at up to the safe height, straight up for the first bit.
+func (s *viamChessChess) goUp(ctx context.Context, at r3.Vector, ws *referenceframe.WorldState) error {
+	path := []waypoint{}
+	for i, p := range s.conf.Motion.upPath(at) {
+		path = append(path, waypoint{p: p, straight: i == 0})
+	}
+	return s.moveAlong(ctx, path, ws)
+}
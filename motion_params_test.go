@@ -0,0 +1,70 @@
+package viamchess
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+func TestMotionConfigDefaults(t *testing.T) {
+	var mc *MotionConfig
+	test.That(t, mc.safeHeight(), test.ShouldEqual, defaultSafeZ)
+	test.That(t, mc.moveOptions(0), test.ShouldBeNil)
+	test.That(t, mc.linear(), test.ShouldBeNil)
+
+	at := r3.Vector{X: 100, Y: 50, Z: 30}
+	test.That(t, mc.downPath(at), test.ShouldResemble, []r3.Vector{at})
+	test.That(t, mc.upPath(at), test.ShouldResemble, []r3.Vector{{X: 100, Y: 50, Z: defaultSafeZ}})
+}
+
+func TestMotionConfig(t *testing.T) {
+	mc := &MotionConfig{
+		SafeHeight:           150,
+		ApproachHeight:       40,
+		RetreatHeight:        60,
+		LineTolerance:        2,
+		OrientationTolerance: 5,
+	}
+	test.That(t, mc.Validate("m"), test.ShouldBeNil)
+
+	at := r3.Vector{X: 100, Y: 50, Z: 30}
+	test.That(t, mc.downPath(at), test.ShouldResemble, []r3.Vector{{X: 100, Y: 50, Z: 70}, at})
+	test.That(t, mc.upPath(at), test.ShouldResemble, []r3.Vector{{X: 100, Y: 50, Z: 90}, {X: 100, Y: 50, Z: 150}})
+
+	// already close to the safe height, go straight
+	high := r3.Vector{X: 100, Y: 50, Z: 120}
+	test.That(t, mc.downPath(high), test.ShouldResemble, []r3.Vector{high})
+	test.That(t, mc.upPath(high), test.ShouldResemble, []r3.Vector{{X: 100, Y: 50, Z: 150}})
+
+	c := mc.linear()
+	test.That(t, len(c.LinearConstraint), test.ShouldEqual, 1)
+	test.That(t, c.LinearConstraint[0].LineToleranceMm, test.ShouldEqual, 2.0)
+	test.That(t, c.LinearConstraint[0].OrientationToleranceDegs, test.ShouldEqual, 5.0)
+
+	test.That(t, (&MotionConfig{RetreatHeight: -1}).Validate("m"), test.ShouldNotBeNil)
+	test.That(t, (&MotionConfig{JointSpeed: -1}).Validate("m"), test.ShouldNotBeNil)
+}
+
+func TestMotionConfigSpeed(t *testing.T) {
+	a := &recordingArm{}
+	s := &viamChessChess{
+		logger:    logging.NewTestLogger(t),
+		conf:      &ChessConfig{Arm: "arm", Gripper: "gripper", Motion: &MotionConfig{JointSpeed: 90, JointAcceleration: 180}},
+		arm:       a,
+		motion:    &planningMotion{},
+		startPose: referenceframe.NewPoseInFrame("world", spatialmath.NewZeroPose()),
+	}
+
+	err := s.moveGripper(context.Background(), r3.Vector{X: 100, Z: 200}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(a.opts), test.ShouldEqual, 1)
+	test.That(t, a.opts[0].MaxVelRads, test.ShouldAlmostEqual, math.Pi/2)
+	test.That(t, a.opts[0].MaxAccRads, test.ShouldAlmostEqual, math.Pi)
+}
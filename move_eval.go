@@ -0,0 +1,130 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/corentings/chess/v2"
+)
+
+const (
+	defaultEvalMillis = 200
+	mateCP            = 10000 // what a mate counts as when working out centipawn loss
+)
+
+// moveEval is how a move compares to the engine's best, all scores for the side that moved.
+type moveEval struct {
+	Best     string `json:"best"`
+	BestCP   int    `json:"best_cp"`
+	PlayedCP int    `json:"played_cp"`
+	Loss     int    `json:"cp_loss"`
+	Class    string `json:"class"`
+}
+
+// moveRecord is one move of the game, as kept in the saved state.
+type moveRecord struct {
+	Move  string    `json:"move"`
+	Color string    `json:"color"`
+	Eval  *moveEval `json:"eval,omitempty"` // only for the human's moves
+}
+
+func (r moveRecord) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"move":  r.Move,
+		"color": r.Color,
+	}
+	if r.Eval != nil {
+		m["best"] = r.Eval.Best
+		m["best_cp"] = r.Eval.BestCP
+		m["played_cp"] = r.Eval.PlayedCP
+		m["cp_loss"] = r.Eval.Loss
+		m["class"] = r.Eval.Class
+	}
+	return m
+}
+
+// classifyMove buckets a move by how many centipawns it gave away.
+func classifyMove(loss int, best bool) string {
+	switch {
+	case best || loss <= 0:
+		return "best"
+	case loss < 50:
+		return "good"
+	case loss < 100:
+		return "inaccuracy"
+	case loss < 300:
+		return "mistake"
+	}
+	return "blunder"
+}
+
+// score is the line's score in centipawns, mates count as mateCP less the moves to get there.
+func (l analysisLine) score() int {
+	switch {
+	case l.mate > 0:
+		return mateCP - l.mate
+	case l.mate < 0:
+		return -mateCP - l.mate
+	}
+	return l.cp
+}
+
+// evaluateMove scores m against the best move in pos.
+func (p *enginePlayer) evaluateMove(ctx context.Context, pos *chess.Position, m *chess.Move, moveTime time.Duration) (*moveEval, error) {
+	lines, err := p.analyze(ctx, pos, 1, moveTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("engine found nothing")
+	}
+
+	ev := &moveEval{Best: lines[0].pv[0].String(), BestCP: lines[0].score()}
+	best := m.String() == ev.Best
+
+	after := pos.Update(m)
+	switch {
+	case after.Status() == chess.Checkmate:
+		ev.PlayedCP = mateCP - 1
+	case after.Status() == chess.Stalemate:
+		ev.PlayedCP = 0
+	case best:
+		ev.PlayedCP = ev.BestCP
+	default:
+		lines, err := p.analyze(ctx, after, 1, moveTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("engine found nothing")
+		}
+		ev.PlayedCP = -lines[0].score()
+	}
+
+	ev.Loss = max(0, ev.BestCP-ev.PlayedCP)
+	ev.Class = classifyMove(ev.Loss, best)
+	return ev, nil
+}
+
+// humanMoved plays the human's move into the game and records how good it was.
+// A failed evaluation is only logged, it's no reason to stop the game.
+func (s *viamChessChess) humanMoved(ctx context.Context, theState *state, m *chess.Move) (*moveRecord, error) {
+	pos := theState.game.Position()
+	err := theState.game.Move(m, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.clock.moved(pos.Turn())
+
+	rec := moveRecord{Move: m.String(), Color: pos.Turn().Name()}
+	rec.Eval, err = s.player.evaluateMove(ctx, pos, m, time.Duration(s.conf.evalMillis())*time.Millisecond)
+	if err != nil {
+		s.logger.Warnf("can't evaluate %v: %v", m, err)
+	} else {
+		s.logger.Infof("human played %v: %s (best %s, lost %d)", m, rec.Eval.Class, rec.Eval.Best, rec.Eval.Loss)
+	}
+
+	theState.moves = append(theState.moves, rec)
+	return &rec, nil
+}
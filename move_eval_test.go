@@ -0,0 +1,80 @@
+package viamchess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+func TestClassifyMove(t *testing.T) {
+	test.That(t, classifyMove(0, false), test.ShouldEqual, "best")
+	test.That(t, classifyMove(20, true), test.ShouldEqual, "best")
+	test.That(t, classifyMove(49, false), test.ShouldEqual, "good")
+	test.That(t, classifyMove(50, false), test.ShouldEqual, "inaccuracy")
+	test.That(t, classifyMove(150, false), test.ShouldEqual, "mistake")
+	test.That(t, classifyMove(300, false), test.ShouldEqual, "blunder")
+}
+
+func TestAnalysisLineScore(t *testing.T) {
+	test.That(t, analysisLine{cp: 35}.score(), test.ShouldEqual, 35)
+	test.That(t, analysisLine{mate: 2}.score(), test.ShouldEqual, mateCP-2)
+	test.That(t, analysisLine{mate: -3}.score(), test.ShouldEqual, -mateCP+3)
+}
+
+func decodeMove(t *testing.T, pos *chess.Position, move string) *chess.Move {
+	t.Helper()
+	m, err := chess.UCINotation{}.Decode(pos, move)
+	test.That(t, err, test.ShouldBeNil)
+	return m
+}
+
+func TestEvaluateMove(t *testing.T) {
+	p, err := newEnginePlayer(&PlayerConfig{Engine: builtinEngine, FallbackDepth: 2}, logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldBeNil)
+	defer p.Close()
+	ctx := context.Background()
+
+	// free queen
+	pos := positionFromFEN(t, "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1")
+
+	ev, err := p.evaluateMove(ctx, pos, decodeMove(t, pos, "d2d5"), time.Second)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ev.Class, test.ShouldEqual, "best")
+	test.That(t, ev.Loss, test.ShouldEqual, 0)
+
+	ev, err = p.evaluateMove(ctx, pos, decodeMove(t, pos, "e1f1"), time.Second)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ev.Best, test.ShouldEqual, "d2d5")
+	test.That(t, ev.Class, test.ShouldEqual, "blunder")
+	test.That(t, ev.Loss, test.ShouldBeGreaterThan, 300)
+
+	// back rank mate
+	pos = positionFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	ev, err = p.evaluateMove(ctx, pos, decodeMove(t, pos, "a1a8"), time.Second)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ev.Class, test.ShouldEqual, "best")
+}
+
+func TestMoveRecordsSaved(t *testing.T) {
+	s := &viamChessChess{games: testGames(t)}
+	ctx := context.Background()
+
+	theState, err := s.getGame(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	theState.moves = append(theState.moves,
+		moveRecord{Move: "e2e4", Color: "White", Eval: &moveEval{Best: "d2d4", BestCP: 30, PlayedCP: 25, Loss: 5, Class: "good"}},
+		moveRecord{Move: "e7e5", Color: "Black"},
+	)
+	test.That(t, s.saveGame(ctx, theState), test.ShouldBeNil)
+
+	theState, err = s.getGame(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, theState.moves, test.ShouldHaveLength, 2)
+	test.That(t, theState.moves[0].toMap()["class"], test.ShouldEqual, "good")
+	test.That(t, theState.moves[1].Eval, test.ShouldBeNil)
+}
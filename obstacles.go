@@ -0,0 +1,117 @@
+package viamchess
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	viz "go.viam.com/rdk/vision"
+)
+
+const (
+	defaultPieceHeight = 90.0 // mm, when nothing on the board has been measured
+	pieceClearance     = 5.0  // mm added above every piece
+	pieceRadius        = .3   // of a square
+)
+
+// toBoard converts a world point to board coordinates in mm.
+func (b *boardPose) toBoard(p r3.Vector) r3.Vector {
+	return spatialmath.Compose(spatialmath.PoseInverse(b.pose), spatialmath.NewPoseFromPoint(p)).Point()
+}
+
+// graveyardSpot is where graveyard slot pos goes, in board coordinates: rows of 8 off the a file.
+func (b *boardPose) graveyardSpot(pos int) (float64, float64) {
+	f := 8 - (pos % 8)
+	ex := 1 + (pos / 8)
+	return b.squareSize/2 - float64(ex*80), (float64(f) - .5) * b.squareSize
+}
+
+// pieceHeight is how far the top of a square's points are above the board, ignoring stray points.
+func (b *boardPose) pieceHeight(pc pointcloud.PointCloud) float64 {
+	heights := []float64{}
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		heights = append(heights, b.toBoard(p).Z)
+		return true
+	})
+	if len(heights) == 0 {
+		return 0
+	}
+	return percentile(heights, .95)
+}
+
+// worldState is the board, every piece on it and the graveyard stacks, as obstacles for motion planning.
+// graveyard is what's in each slot, -1 for empty. Squares and slots in skip are left out, they're the
+// ones we're about to touch.
+func (b *boardPose) worldState(objects []*viz.Object, graveyard []int, skip ...string) (*referenceframe.WorldState, error) {
+	link, err := b.link()
+	if err != nil {
+		return nil, err
+	}
+
+	skipped := map[string]bool{}
+	for _, s := range skip {
+		skipped[s] = true
+	}
+
+	// spatialmath has no cylinder, so each piece is the box around one: as tall as the piece, where a capsule
+	// would either stick up past it or round off its top edge
+	width := 2 * pieceRadius * b.squareSize
+	cylinder := func(x, y, height float64, label string) (spatialmath.Geometry, error) {
+		h := height + pieceClearance
+		return spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: x, Y: y, Z: h / 2}), r3.Vector{X: width, Y: width, Z: h}, label)
+	}
+
+	geoms := []spatialmath.Geometry{}
+	tallest := 0.0
+
+	for _, o := range objects {
+		label := o.Geometry.Label()
+		if strings.HasSuffix(label, "-0") || len(label) < 2 {
+			continue
+		}
+		name := label[0:2]
+
+		height := b.pieceHeight(o)
+		tallest = max(tallest, height)
+		if skipped[name] || height <= 0 {
+			continue
+		}
+
+		x, y, err := b.squareSpot(name)
+		if err != nil {
+			return nil, err
+		}
+
+		g, err := cylinder(x, y, height, "piece-"+name)
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, g)
+	}
+
+	if tallest <= 0 {
+		tallest = defaultPieceHeight
+	}
+
+	for idx, p := range graveyard {
+		name := fmt.Sprintf("X%d", idx)
+		if p < 0 || skipped[name] {
+			continue
+		}
+		x, y := b.graveyardSpot(idx)
+		g, err := cylinder(x, y, tallest, "graveyard-"+name)
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, g)
+	}
+
+	return referenceframe.NewWorldState(
+		[]*referenceframe.GeometriesInFrame{referenceframe.NewGeometriesInFrame("board", geoms)},
+		[]*referenceframe.LinkInFrame{link},
+	)
+}
@@ -0,0 +1,72 @@
+package viamchess
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	viz "go.viam.com/rdk/vision"
+	"go.viam.com/test"
+)
+
+func TestBoardWorldState(t *testing.T) {
+	b := &boardPose{
+		pose:       spatialmath.NewPose(r3.Vector{X: 200, Y: -100, Z: 20}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90}),
+		squareSize: 40,
+	}
+
+	square := func(name string, color int, height float64) *viz.Object {
+		pc := pointcloud.NewBasicEmpty()
+		x, y, err := b.squareSpot(name)
+		test.That(t, err, test.ShouldBeNil)
+		for h := 0.0; h <= height; h += 5 {
+			for _, dx := range []float64{-5, 0, 5} {
+				test.That(t, pc.Set(b.point(x+dx, y, h), nil), test.ShouldBeNil)
+			}
+		}
+		o, err := viz.NewObjectWithLabel(pc, name+"-"+string(rune('0'+color)), nil)
+		test.That(t, err, test.ShouldBeNil)
+		return o
+	}
+
+	objects := []*viz.Object{
+		square("e2", 1, 50),
+		square("e4", 0, 0),
+		square("d8", 2, 80),
+		square("e7", 2, 50),
+	}
+
+	ws, err := b.worldState(objects, []int{3, -1, 9}, "e7", "X2")
+	test.That(t, err, test.ShouldBeNil)
+
+	test.That(t, len(ws.Transforms()), test.ShouldEqual, 1)
+	test.That(t, ws.Transforms()[0].Name(), test.ShouldEqual, "board")
+
+	test.That(t, len(ws.Obstacles()), test.ShouldEqual, 1)
+	obstacles := ws.Obstacles()[0]
+	test.That(t, obstacles.Parent(), test.ShouldEqual, "board")
+	test.That(t, ws.ObstacleNames(), test.ShouldResemble, map[string]bool{
+		"piece-e2":     true,
+		"piece-d8":     true,
+		"graveyard-X0": true,
+	})
+
+	e2 := obstacles.GeometryByName("piece-e2")
+	test.That(t, e2.Pose().Point().X, test.ShouldAlmostEqual, 4.5*40)
+	test.That(t, e2.Pose().Point().Y, test.ShouldAlmostEqual, 1.5*40)
+	test.That(t, e2.Pose().Point().Z, test.ShouldAlmostEqual, (50+pieceClearance)/2, 3)
+
+	// no higher than the piece, and all of its footprint
+	dims := e2.ToProtobuf().GetBox().GetDimsMm()
+	test.That(t, dims.Z, test.ShouldAlmostEqual, 50+pieceClearance, 3)
+	test.That(t, dims.X, test.ShouldAlmostEqual, 2*pieceRadius*40)
+
+	// graveyard stacks are as tall as the tallest piece
+	x0 := obstacles.GeometryByName("graveyard-X0")
+	x, y := b.graveyardSpot(0)
+	test.That(t, x0.Pose().Point().X, test.ShouldAlmostEqual, x)
+	test.That(t, x0.Pose().Point().Y, test.ShouldAlmostEqual, y)
+	test.That(t, x0.Pose().Point().Z, test.ShouldAlmostEqual, (80+pieceClearance)/2, 3)
+}
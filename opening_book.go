@@ -0,0 +1,78 @@
+package viamchess
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/corentings/chess/v2"
+)
+
+const defaultBookDepth = 10
+
+// openingBook picks moves from a polyglot book for the first maxDepth moves of a game.
+type openingBook struct {
+	book     *chess.PolyglotBook
+	maxDepth int // in full moves
+}
+
+func loadOpeningBook(fn string, maxDepth int) (*openingBook, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("can't open book: %w", err)
+	}
+	defer f.Close()
+
+	book, err := chess.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("can't read book %s: %w", fn, err)
+	}
+
+	return &openingBook{book: book, maxDepth: maxDepth}, nil
+}
+
+// move picks a book move for the position, weighted by how often it's played. nil if we're out of book.
+func (b *openingBook) move(pos *chess.Position, rng *rand.Rand) (*chess.Move, error) {
+	moveNumber := (pos.Ply()-1)/2 + 1
+	if b.maxDepth > 0 && moveNumber > b.maxDepth {
+		return nil, nil
+	}
+
+	hash, err := chess.NewZobristHasher().HashPosition(pos.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// only moves that are legal here, a hash collision could give us anything
+	legal := pos.ValidMoves()
+	candidates := []*chess.Move{}
+	weights := []int{}
+	total := 0
+	for _, e := range b.book.FindMoves(chess.ZobristHashToUint64(hash)) {
+		bm := chess.DecodeMove(e.Move).ToMove()
+		for i := range legal {
+			if legal[i].S1() == bm.S1() && legal[i].S2() == bm.S2() && legal[i].Promo() == bm.Promo() {
+				candidates = append(candidates, &legal[i])
+				weights = append(weights, int(e.Weight))
+				total += int(e.Weight)
+				break
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if total == 0 {
+		return candidates[rng.Intn(len(candidates))], nil
+	}
+
+	r := rng.Intn(total)
+	for i, w := range weights {
+		if r < w {
+			return candidates[i], nil
+		}
+		r -= w
+	}
+	return candidates[len(candidates)-1], nil
+}
@@ -0,0 +1,118 @@
+package viamchess
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/test"
+)
+
+type bookEntry struct {
+	fen    string
+	move   string // from and to, polyglot style so castling is king takes rook
+	weight uint16
+}
+
+func writeBook(t *testing.T, entries []bookEntry) string {
+	t.Helper()
+	data := []byte{}
+	for _, e := range entries {
+		hash, err := chess.NewZobristHasher().HashPosition(e.fen)
+		test.That(t, err, test.ShouldBeNil)
+
+		pm := chess.PolyglotMove{
+			FromFile: int(e.move[0] - 'a'),
+			FromRank: int(e.move[1] - '1'),
+			ToFile:   int(e.move[2] - 'a'),
+			ToRank:   int(e.move[3] - '1'),
+		}
+
+		buf := make([]byte, 16)
+		binary.BigEndian.PutUint64(buf[0:8], chess.ZobristHashToUint64(hash))
+		binary.BigEndian.PutUint16(buf[8:10], pm.Encode())
+		binary.BigEndian.PutUint16(buf[10:12], e.weight)
+		data = append(data, buf...)
+	}
+
+	fn := filepath.Join(t.TempDir(), "book.bin")
+	test.That(t, os.WriteFile(fn, data, 0o644), test.ShouldBeNil)
+	return fn
+}
+
+const italianFEN = "r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4"
+
+func TestOpeningBook(t *testing.T) {
+	start := chess.StartingPosition().String()
+	fn := writeBook(t, []bookEntry{
+		{start, "e2e4", 3},
+		{start, "d2d4", 1},
+		{start, "e2e5", 100}, // not legal, ignored
+		{italianFEN, "e1h1", 1},
+	})
+
+	book, err := loadOpeningBook(fn, 4)
+	test.That(t, err, test.ShouldBeNil)
+
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+	for range 400 {
+		m, err := book.move(chess.StartingPosition(), rng)
+		test.That(t, err, test.ShouldBeNil)
+		counts[m.String()]++
+	}
+	test.That(t, len(counts), test.ShouldEqual, 2)
+	test.That(t, counts["e2e4"], test.ShouldBeBetween, 250, 350)
+
+	italian := positionFromFEN(t, italianFEN)
+	m, err := book.move(italian, rng)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldEqual, "e1g1")
+	test.That(t, m.HasTag(chess.KingSideCastle), test.ShouldBeTrue)
+
+	// past the book depth
+	book.maxDepth = 3
+	m, err = book.move(italian, rng)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m, test.ShouldBeNil)
+
+	// not in the book
+	m, err = book.move(positionFromFEN(t, "4k3/8/8/8/8/8/8/4K3 w - - 0 1"), rng)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m, test.ShouldBeNil)
+
+	_, err = loadOpeningBook(filepath.Join(t.TempDir(), "nope.bin"), 0)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestEnginePlayerBook(t *testing.T) {
+	fn := writeBook(t, []bookEntry{{chess.StartingPosition().String(), "g1f3", 1}})
+
+	p, err := newEnginePlayer(
+		&PlayerConfig{Engine: builtinEngine, FallbackDepth: 1, Book: fn},
+		logging.NewTestLogger(t),
+		rand.New(rand.NewSource(1)),
+	)
+	test.That(t, err, test.ShouldBeNil)
+	defer p.Close()
+
+	game := chess.NewGame()
+	m, err := p.pickMove(context.Background(), game, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.String(), test.ShouldEqual, "g1f3")
+
+	// out of book falls through to the engine
+	test.That(t, game.Move(m, nil), test.ShouldBeNil)
+	m, err = p.pickMove(context.Background(), game, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m, test.ShouldNotBeNil)
+
+	_, err = newEnginePlayer(&PlayerConfig{Engine: builtinEngine, Book: fn + "x"}, logging.NewTestLogger(t), nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
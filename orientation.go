@@ -0,0 +1,99 @@
+package viamchess
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+)
+
+// defaultTilts are tried, in turn, without configured tilts: straight down, then leaning 0.2 each way.
+var defaultTilts = [][]float64{
+	{0, 0},
+	{.2, 0},
+	{-.2, 0},
+	{0, .2},
+	{0, -.2},
+}
+
+// lean is how the gripper used to lean to reach p, only with "lean": out past x 300, and over to the
+// side past y -300. It's only right for the arm it was worked out on.
+func lean(p r3.Vector) []float64 {
+	t := []float64{0, 0}
+	if p.X > 300 {
+		t[0] = (p.X - 300) / 1000
+	}
+	if p.Y < -300 {
+		t[1] = (p.Y + 300) / 300
+		t[0] += .2
+	}
+	return t
+}
+
+// OrientationRegion is a box on the table where the gripper needs to lean to reach, ox and oy are
+// added to the straight down orientation vector.
+type OrientationRegion struct {
+	MinX float64 `json:"min-x"`
+	MaxX float64 `json:"max-x"`
+	MinY float64 `json:"min-y"`
+	MaxY float64 `json:"max-y"`
+	OX   float64 `json:"ox"`
+	OY   float64 `json:"oy"`
+}
+
+func (r *OrientationRegion) contains(p r3.Vector) bool {
+	return p.X >= r.MinX && p.X <= r.MaxX && p.Y >= r.MinY && p.Y <= r.MaxY
+}
+
+// OrientationConfig is how the gripper reaches the table. The first region a point is in goes first,
+// then lean if it's on, then each of the tilts until one can be planned.
+type OrientationConfig struct {
+	Regions []OrientationRegion `json:"regions"`
+	Lean    bool                `json:"lean"`  // try the old fixed lean too
+	Tilts   [][]float64         `json:"tilts"` // [ox, oy], defaultTilts without them
+}
+
+func (oc *OrientationConfig) Validate(path string) error {
+	for i, r := range oc.Regions {
+		if r.MinX >= r.MaxX || r.MinY >= r.MaxY {
+			return fmt.Errorf("%s.regions.%d: min has to be less than max", path, i)
+		}
+	}
+	for i, t := range oc.Tilts {
+		if len(t) != 2 {
+			return fmt.Errorf("%s.tilts.%d: need [ox, oy]", path, i)
+		}
+	}
+	return nil
+}
+
+// tilts are the [ox, oy] to try for a point, best first, without repeats.
+func (oc *OrientationConfig) tilts(p r3.Vector) [][]float64 {
+	all := [][]float64{}
+	tilts := defaultTilts
+	if oc != nil {
+		for _, r := range oc.Regions {
+			if r.contains(p) {
+				all = append(all, []float64{r.OX, r.OY})
+				break
+			}
+		}
+		if oc.Lean {
+			all = append(all, lean(p))
+		}
+		if len(oc.Tilts) > 0 {
+			tilts = oc.Tilts
+		}
+	}
+	all = append(all, tilts...)
+
+	out := [][]float64{}
+	seen := map[[2]float64]bool{}
+	for _, t := range all {
+		k := [2]float64{t[0], t[1]}
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
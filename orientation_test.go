@@ -0,0 +1,65 @@
+package viamchess
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/test"
+)
+
+func TestOrientationTilts(t *testing.T) {
+	var none *OrientationConfig
+	test.That(t, none.tilts(r3.Vector{}), test.ShouldResemble, defaultTilts)
+
+	// the defaults don't depend on where it is
+	test.That(t, none.tilts(r3.Vector{X: 400, Y: -450}), test.ShouldResemble, defaultTilts)
+
+	// the old lean only when asked for
+	leaning := &OrientationConfig{Lean: true}
+	got := leaning.tilts(r3.Vector{X: 500})
+	test.That(t, got[0][0], test.ShouldAlmostEqual, .2)
+	test.That(t, got[0][1], test.ShouldEqual, 0.0)
+	test.That(t, len(got), test.ShouldEqual, len(defaultTilts))
+
+	got = leaning.tilts(r3.Vector{X: 400, Y: -450})
+	test.That(t, got[0][0], test.ShouldAlmostEqual, .3)
+	test.That(t, got[0][1], test.ShouldAlmostEqual, -.5)
+	test.That(t, len(got), test.ShouldEqual, len(defaultTilts)+1)
+
+	oc := &OrientationConfig{
+		Regions: []OrientationRegion{
+			{MinX: 300, MaxX: 1000, MinY: -1000, MaxY: 1000, OX: .2},
+			{MinX: -1000, MaxX: 1000, MinY: -1000, MaxY: -300, OX: .2, OY: -.3},
+		},
+	}
+	test.That(t, oc.Validate("o"), test.ShouldBeNil)
+
+	// first region wins, then the defaults, without trying .2, 0 twice
+	got = oc.tilts(r3.Vector{X: 400, Y: -400})
+	test.That(t, got[0], test.ShouldResemble, []float64{.2, 0})
+	test.That(t, len(got), test.ShouldEqual, len(defaultTilts))
+
+	got = oc.tilts(r3.Vector{X: 0, Y: -400})
+	test.That(t, got[0], test.ShouldResemble, []float64{.2, -.3})
+	test.That(t, len(got), test.ShouldEqual, len(defaultTilts)+1)
+
+	// with lean on, it goes after the region
+	oc.Lean = true
+	got = oc.tilts(r3.Vector{X: 0, Y: -400})
+	test.That(t, got[0], test.ShouldResemble, []float64{.2, -.3})
+	test.That(t, got[1][1], test.ShouldAlmostEqual, -1.0/3)
+	oc.Lean = false
+
+	test.That(t, oc.tilts(r3.Vector{}), test.ShouldResemble, defaultTilts)
+
+	oc.Tilts = [][]float64{{0, 0}}
+	test.That(t, oc.tilts(r3.Vector{X: 400}), test.ShouldResemble, [][]float64{{.2, 0}, {0, 0}})
+	test.That(t, oc.tilts(r3.Vector{X: -400}), test.ShouldResemble, [][]float64{{0, 0}})
+
+	oc.Tilts = [][]float64{{0}}
+	test.That(t, oc.Validate("o"), test.ShouldNotBeNil)
+	oc.Tilts = nil
+	oc.Regions[0].MaxX = 200
+	test.That(t, oc.Validate("o"), test.ShouldNotBeNil)
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
+	"sync"
 
 	"github.com/golang/geo/r3"
 
@@ -16,6 +16,7 @@ import (
 	"go.viam.com/rdk/components/camera"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
 	"go.viam.com/rdk/resource"
 	"go.viam.com/rdk/rimage"
 	"go.viam.com/rdk/robot/framesystem"
@@ -43,15 +44,79 @@ func init() {
 
 type PieceFinderConfig struct {
 	Input string // this is the cropped camera for the board, TODO: what orientation???
+
+	// Corners of the board in input image pixels: top-left (h1), top-right (a1), bottom-right (a8), bottom-left (h8).
+	Corners [][]int `json:"corners"`
+	// FindBoard locates the corners in every image instead of using Corners.
+	FindBoard bool `json:"find-board"`
+
+	// SquareInset is the fraction of a square ignored on each side (default .1).
+	SquareInset *float64 `json:"square-inset"`
+	// MaxPieceHeight in mm, points higher above the board are ignored (default 150).
+	MaxPieceHeight float64 `json:"max-piece-height"`
+}
+
+func (cfg *PieceFinderConfig) squareOptions() squareOptions {
+	opts := squareOptions{inset: .1, maxHeight: cfg.MaxPieceHeight}
+	if cfg.SquareInset != nil {
+		opts.inset = *cfg.SquareInset
+	}
+	if opts.maxHeight <= 0 {
+		opts.maxHeight = 150
+	}
+	return opts
 }
 
 func (cfg *PieceFinderConfig) Validate(path string) ([]string, []string, error) {
 	if cfg.Input == "" {
 		return nil, nil, fmt.Errorf("need an input")
 	}
+	if len(cfg.Corners) > 0 {
+		if _, err := cfg.cornerPoints(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.SquareInset != nil && (*cfg.SquareInset < 0 || *cfg.SquareInset >= .5) {
+		return nil, nil, fmt.Errorf("square-inset has to be at least 0 and less than .5")
+	}
 	return []string{cfg.Input}, nil, nil
 }
 
+func (cfg *PieceFinderConfig) cornerPoints() ([]image.Point, error) {
+	if len(cfg.Corners) != 4 {
+		return nil, fmt.Errorf("corners needs 4 points, got %d", len(cfg.Corners))
+	}
+	pts := []image.Point{}
+	for _, c := range cfg.Corners {
+		if len(c) != 2 {
+			return nil, fmt.Errorf("each corner needs to be [x, y], got %v", c)
+		}
+		pts = append(pts, image.Point{c[0], c[1]})
+	}
+	return pts, nil
+}
+
+// grid returns the square layout for img, from the configured or detected corners.
+func (bc *PieceFinder) grid(img image.Image) (*boardGrid, error) {
+	if len(bc.conf.Corners) > 0 {
+		corners, err := bc.conf.cornerPoints()
+		if err != nil {
+			return nil, err
+		}
+		return newBoardGrid(corners)
+	}
+
+	if bc.conf.FindBoard {
+		corners, err := findBoard(img)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find board: %w", err)
+		}
+		return newBoardGrid(corners)
+	}
+
+	return defaultBoardGrid(img.Bounds()), nil
+}
+
 func newPieceFinder(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (vision.Service, error) {
 	conf, err := resource.NativeConfig[*PieceFinderConfig](rawConf)
 	if err != nil {
@@ -99,6 +164,9 @@ type PieceFinder struct {
 	rfs   framesystem.Service
 	input camera.Camera
 	props camera.Properties
+
+	boardLock sync.Mutex
+	board     *boardPose // in the world, smoothed over captures
 }
 
 type squareInfo struct {
@@ -108,17 +176,40 @@ type squareInfo struct {
 
 	originalBounds image.Rectangle
 
-	color int // 0,1,2
+	color  int     // 0,1,2
+	height float64 // of the piece above the board in mm, 0 if unknown
 
 	pc pointcloud.PointCloud
 }
 
+// BoardDebugImageHack splits the image into squares assuming the board is the largest square centered in it.
 func BoardDebugImageHack(srcImg image.Image, pc pointcloud.PointCloud, props camera.Properties) (image.Image, []squareInfo, error) {
-	dst := image.NewRGBA(image.Rect(0, 0, srcImg.Bounds().Max.Y, srcImg.Bounds().Max.Y))
+	cfg := &PieceFinderConfig{}
+	grid := defaultBoardGrid(srcImg.Bounds())
+	return boardDebugImage(srcImg, pc, props, grid, estimateBoardFrame(pc, props, grid), cfg.squareOptions())
+}
 
-	xOffset := (srcImg.Bounds().Max.X - srcImg.Bounds().Max.Y) / 2
+// estimateBoardFrame returns nil if the board plane can't be found, in which case we can only go
+// by where points land in the image.
+func estimateBoardFrame(pc pointcloud.PointCloud, props camera.Properties, grid *boardGrid) *boardFrame {
+	frame, err := boardFrameFromPlane(pc, props, grid)
+	if err != nil {
+		return nil
+	}
+	return frame
+}
+
+// boardDebugImage splits the image and point cloud into squares using grid and frame, and draws a
+// rectified board with each square labeled. frame can be nil.
+func boardDebugImage(srcImg image.Image, pc pointcloud.PointCloud, props camera.Properties, grid *boardGrid, frame *boardFrame, opts squareOptions) (image.Image, []squareInfo, error) {
+	squareSize := max(int(grid.sideLength())/8, 1)
+
+	squarePcs, err := splitBoardCloud(pc, props, grid, frame, opts)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	squareSize := srcImg.Bounds().Max.Y / 8
+	dst := image.NewRGBA(image.Rect(0, 0, squareSize*8, squareSize*8))
 
 	squares := []squareInfo{}
 
@@ -126,37 +217,32 @@ func BoardDebugImageHack(srcImg image.Image, pc pointcloud.PointCloud, props cam
 		for file := 'a'; file <= 'h'; file++ {
 			name := fmt.Sprintf("%s%d", string([]byte{byte(file)}), rank)
 
-			xStartOffset := int(('h' - file)) * squareSize
-			yStartOffset := (rank - 1) * squareSize
-
-			srcRect := image.Rect(
-				xStartOffset+xOffset,
-				yStartOffset,
-				xStartOffset+xOffset+squareSize,
-				yStartOffset+squareSize,
-			)
+			quad := grid.squareQuad(file, rank, 0)
+			srcRect := quadBounds(quad)
 
+			u, v := squareCell(file, rank)
 			dstRect := image.Rect(
-				xStartOffset,
-				yStartOffset,
-				xStartOffset+squareSize,
-				yStartOffset+squareSize,
+				int(u)*squareSize,
+				int(v)*squareSize,
+				int(u+1)*squareSize,
+				int(v+1)*squareSize,
 			)
 
-			subPc, err := touch.PCLimitToImageBoxes(pc, []*image.Rectangle{&srcRect}, nil, props)
-			if err != nil {
-				return nil, nil, err
+			subPc, ok := squarePcs[name]
+			if !ok || subPc.Size() == 0 {
+				return nil, nil, fmt.Errorf("pc for %s is empty in BoardDebugImage", name)
 			}
 
-			if subPc.Size() == 0 {
-				return nil, nil, fmt.Errorf("pc for %s is empty in BoardDebugImageHack", name)
+			pieceColor, height := 0, 0.0
+			if frame != nil {
+				pieceColor, height = estimatePiece(subPc, frame)
+			} else {
+				pieceColor = estimatePieceColor(subPc)
 			}
-
-			pieceColor := estimatePieceColor(subPc)
 			colorNames := []string{"", "W", "B"}
 			meta := colorNames[pieceColor]
 
-			draw.Draw(dst, dstRect, srcImg, srcRect.Min, draw.Src)
+			drawSquare(dst, dstRect, srcImg, grid)
 
 			// put name in the middle of that square
 			textX := dstRect.Min.X + squareSize/2 - len(name)*3
@@ -169,6 +255,7 @@ func BoardDebugImageHack(srcImg image.Image, pc pointcloud.PointCloud, props cam
 				name,
 				srcRect,
 				pieceColor,
+				height,
 				subPc,
 			})
 		}
@@ -177,7 +264,20 @@ func BoardDebugImageHack(srcImg image.Image, pc pointcloud.PointCloud, props cam
 	return dst, squares, nil
 }
 
+// drawSquare fills dstRect of the rectified board by sampling srcImg through the grid.
+func drawSquare(dst *image.RGBA, dstRect image.Rectangle, srcImg image.Image, grid *boardGrid) {
+	scale := 8 / float64(dst.Bounds().Dx())
+	bounds := srcImg.Bounds()
+	for y := dstRect.Min.Y; y < dstRect.Max.Y; y++ {
+		for x := dstRect.Min.X; x < dstRect.Max.X; x++ {
+			p := grid.imagePoint((float64(x)+.5)*scale, (float64(y)+.5)*scale)
+			dst.Set(x, y, bilinearSample(srcImg, p.x, p.y, bounds))
+		}
+	}
+}
+
 // 0 - blank, 1 - white, 2 - black
+// Only works when the camera looks straight down at the board, estimatePiece is used when we have a board plane.
 func estimatePieceColor(pc pointcloud.PointCloud) int {
 	minZ := pc.MetaData().MaxZ - minPieceSize
 	var totalR, totalG, totalB float64
@@ -222,7 +322,51 @@ func drawString(dst *image.RGBA, x, y int, s string, c color.Color) {
 }
 
 func (bc *PieceFinder) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
-	return nil, fmt.Errorf("DoCommand not supported")
+	if cmd["board"] == true {
+		b := bc.boardPose()
+		if b == nil {
+			_, err := bc.CaptureAllFromCamera(ctx, "", viscapture.CaptureOptions{}, nil)
+			if err != nil {
+				return nil, err
+			}
+			b = bc.boardPose()
+		}
+		if b == nil {
+			return nil, fmt.Errorf("no board pose, couldn't find the board plane")
+		}
+		return b.toMap(), nil
+	}
+
+	return nil, fmt.Errorf("bad cmd %v", cmd)
+}
+
+func (bc *PieceFinder) boardPose() *boardPose {
+	bc.boardLock.Lock()
+	defer bc.boardLock.Unlock()
+	return bc.board
+}
+
+// updateBoardPose moves the board estimate from the camera frame to the world and folds it into
+// the running estimate.
+func (bc *PieceFinder) updateBoardPose(ctx context.Context, frame *boardFrame) error {
+	if bc.rfs == nil {
+		return fmt.Errorf("no framesystem")
+	}
+
+	camPose, err := frame.a1Pose()
+	if err != nil {
+		return err
+	}
+
+	world, err := bc.rfs.TransformPose(ctx, referenceframe.NewPoseInFrame(bc.conf.Input, camPose), referenceframe.World, nil)
+	if err != nil {
+		return err
+	}
+
+	bc.boardLock.Lock()
+	defer bc.boardLock.Unlock()
+	bc.board = smoothBoardPose(bc.board, &boardPose{pose: world.Pose(), squareSize: frame.squareSize()})
+	return nil
 }
 
 func (bc *PieceFinder) Name() resource.Name {
@@ -284,13 +428,30 @@ func (bc *PieceFinder) CaptureAllFromCamera(ctx context.Context, cameraName stri
 		return ret, err
 	}
 
-	_, span2 = trace.StartSpan(ctx, "PieceFinder::CaptureAllFromCamera::BoardDebugImageHack")
-	dst, squares, err := BoardDebugImageHack(ret.Image, pc, bc.props)
+	grid, err := bc.grid(ret.Image)
+	if err != nil {
+		return ret, err
+	}
+
+	_, span2 = trace.StartSpan(ctx, "PieceFinder::CaptureAllFromCamera::boardDebugImage")
+	frame, err := boardFrameFromPlane(pc, bc.props, grid)
+	if err != nil {
+		bc.logger.Debugf("can't find board plane: %v", err)
+		frame = nil
+	}
+	dst, squares, err := boardDebugImage(ret.Image, pc, bc.props, grid, frame, bc.conf.squareOptions())
 	span2.End()
 	if err != nil {
 		return ret, err
 	}
 
+	if frame != nil {
+		err = bc.updateBoardPose(ctx, frame)
+		if err != nil {
+			bc.logger.Warnf("can't update board pose: %v", err)
+		}
+	}
+
 	_, span2 = trace.StartSpan(ctx, "PieceFinder::CaptureAllFromCamera::Finish")
 	defer span2.End()
 
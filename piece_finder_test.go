@@ -24,3 +24,20 @@ func TestPieceFinder1(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 
 }
+
+func TestPieceFinderSquareInset(t *testing.T) {
+	cfg := &PieceFinderConfig{Input: "cam"}
+	test.That(t, cfg.squareOptions().inset, test.ShouldEqual, .1)
+
+	zero := 0.0
+	cfg.SquareInset = &zero
+	test.That(t, cfg.squareOptions().inset, test.ShouldEqual, 0.0)
+	_, _, err := cfg.Validate("")
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, bad := range []float64{-.1, .5} {
+		cfg.SquareInset = &bad
+		_, _, err = cfg.Validate("")
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
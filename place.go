@@ -0,0 +1,156 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/vision/viscapture"
+	"go.viam.com/utils/trace"
+)
+
+// tippedRatio is how much shorter than before a piece can look before we call it knocked over.
+const tippedRatio = .6
+
+const (
+	placeOK        = ""
+	placeMissing   = "missing"
+	placeWrong     = "wrong color"
+	placeTipped    = "tipped over"
+	placeDisturbed = "disturbed"
+)
+
+// neighbours are the squares around sq.
+func neighbours(sq chess.Square) []chess.Square {
+	out := []chess.Square{}
+	for df := -1; df <= 1; df++ {
+		for dr := -1; dr <= 1; dr++ {
+			f, r := int(sq.File())+df, int(sq.Rank())+dr
+			if (df == 0 && dr == 0) || f < 0 || f > 7 || r < 0 || r > 7 {
+				continue
+			}
+			out = append(out, chess.NewSquare(chess.File(f), chess.Rank(r)))
+		}
+	}
+	return out
+}
+
+// applyStep updates a color map for a step, squares only, the graveyard isn't on it.
+func applyStep(colors map[chess.Square]int, st journalStep) {
+	if sq, ok := parseSquare(st.From); ok {
+		colors[sq] = 0
+	}
+	if sq, ok := parseSquare(st.To); ok {
+		colors[sq] = int(chess.Piece(st.Piece).Color())
+	}
+}
+
+// checkPlacement compares what we see around to with what should be there. heightBefore is the piece's
+// height before it was picked up, 0 if we don't know it, heightNow what's on to now.
+// For a missing piece it also says where it went, if it's on a neighbouring square.
+func checkPlacement(expect, seen map[chess.Square]int, to chess.Square, heightBefore, heightNow float64) (string, chess.Square) {
+	if seen[to] == 0 {
+		for _, n := range neighbours(to) {
+			if seen[n] == expect[to] && expect[n] == 0 {
+				return placeMissing, n
+			}
+		}
+		return placeMissing, chess.NoSquare
+	}
+	if seen[to] != expect[to] {
+		return placeWrong, chess.NoSquare
+	}
+	if heightBefore > 0 && heightNow < heightBefore*tippedRatio {
+		return placeTipped, chess.NoSquare
+	}
+	for _, n := range neighbours(to) {
+		if seen[n] != expect[n] {
+			return placeDisturbed, n
+		}
+	}
+	return placeOK, chess.NoSquare
+}
+
+// heightAt is how tall what's on a square is, 0 if we can't tell.
+func (s *viamChessChess) heightAt(data viscapture.VisCapture, pos string) float64 {
+	if s.board == nil {
+		return 0
+	}
+	if _, ok := parseSquare(pos); !ok {
+		return 0
+	}
+	o := s.findObject(data, pos)
+	if o == nil {
+		return 0
+	}
+	return s.board.pieceHeight(o)
+}
+
+// verifyPlace goes back to look at the board after a piece was put down on to. A piece that landed on the square
+// next door gets one try at moving it over, anything else is for the operator. pc is the piece that was put down,
+// the game doesn't have it there yet. expect is what every square should have now, nil to not check.
+func (s *viamChessChess) verifyPlace(ctx context.Context, theState *state, to string, pc chess.Piece, expect map[chess.Square]int, heightBefore float64, retry bool) error {
+	sq, ok := parseSquare(to)
+	if !s.conf.VerifyPlace || expect == nil || !ok {
+		return nil
+	}
+
+	ctx, span := trace.StartSpan(ctx, "verifyPlace")
+	defer span.End()
+
+	err := s.goToStart(ctx)
+	if err != nil {
+		return err
+	}
+
+	all, err := s.capture(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen, err := s.boardColors(all)
+	if err != nil {
+		return err
+	}
+
+	problem, where := checkPlacement(expect, seen, sq, heightBefore, s.heightAt(all, to))
+	switch {
+	case problem == placeOK:
+		return nil
+	case problem == placeMissing && where != chess.NoSquare && retry:
+		s.logger.Warnf("piece for %s landed on %s, moving it over", to, where)
+		err := s.transfer(ctx, all, theState, where.String(), to, pc, nil)
+		if err != nil {
+			return err
+		}
+		return s.verifyPlace(ctx, theState, to, pc, expect, heightBefore, false)
+	}
+
+	msg := fmt.Sprintf("piece on %s is %s", to, problem)
+	if where != chess.NoSquare {
+		msg += fmt.Sprintf(" (%s)", where)
+	}
+	s.setAlert(msg + ", fix it and recover")
+	return fmt.Errorf("%s", msg)
+}
+
+// setAlert is for something only a person can fix, it shows in the status until the next move that works.
+func (s *viamChessChess) setAlert(msg string) {
+	s.logger.Errorf("alert: %s", msg)
+	s.alertLock.Lock()
+	defer s.alertLock.Unlock()
+	s.alert = msg
+}
+
+func (s *viamChessChess) clearAlert() {
+	s.alertLock.Lock()
+	defer s.alertLock.Unlock()
+	s.alert = ""
+}
+
+func (s *viamChessChess) getAlert() string {
+	s.alertLock.Lock()
+	defer s.alertLock.Unlock()
+	return s.alert
+}
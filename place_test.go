@@ -0,0 +1,63 @@
+package viamchess
+
+import (
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/test"
+)
+
+func TestNeighbours(t *testing.T) {
+	test.That(t, neighbours(chess.A1), test.ShouldHaveLength, 3)
+	test.That(t, neighbours(chess.A4), test.ShouldHaveLength, 5)
+	test.That(t, neighbours(chess.E4), test.ShouldHaveLength, 8)
+	test.That(t, neighbours(chess.H8), test.ShouldContain, chess.G7)
+}
+
+func TestCheckPlacement(t *testing.T) {
+	start := positionColors(chess.StartingPosition())
+	expect := positionColors(chess.StartingPosition())
+	applyStep(expect, journalStep{"e2", "e4", int(chess.WhitePawn)})
+	test.That(t, expect[chess.E2], test.ShouldEqual, 0)
+	test.That(t, expect[chess.E4], test.ShouldEqual, int(chess.White))
+
+	copyColors := func(m map[chess.Square]int) map[chess.Square]int {
+		out := map[chess.Square]int{}
+		for k, v := range m {
+			out[k] = v
+		}
+		return out
+	}
+
+	seen := copyColors(expect)
+	problem, _ := checkPlacement(expect, seen, chess.E4, 40, 41)
+	test.That(t, problem, test.ShouldEqual, placeOK)
+
+	problem, _ = checkPlacement(expect, seen, chess.E4, 40, 15)
+	test.That(t, problem, test.ShouldEqual, placeTipped)
+
+	// landed next door
+	seen = copyColors(expect)
+	seen[chess.E4], seen[chess.F4] = 0, int(chess.White)
+	problem, where := checkPlacement(expect, seen, chess.E4, 0, 0)
+	test.That(t, problem, test.ShouldEqual, placeMissing)
+	test.That(t, where, test.ShouldEqual, chess.F4)
+
+	// gone
+	problem, where = checkPlacement(expect, start, chess.E4, 0, 0)
+	test.That(t, problem, test.ShouldEqual, placeMissing)
+	test.That(t, where, test.ShouldEqual, chess.NoSquare)
+
+	seen = copyColors(expect)
+	seen[chess.E4] = int(chess.Black)
+	problem, _ = checkPlacement(expect, seen, chess.E4, 0, 0)
+	test.That(t, problem, test.ShouldEqual, placeWrong)
+
+	// something else ended up on d3
+	seen = copyColors(expect)
+	seen[chess.D3] = int(chess.White)
+	problem, where = checkPlacement(expect, seen, chess.E4, 0, 0)
+	test.That(t, problem, test.ShouldEqual, placeDisturbed)
+	test.That(t, where, test.ShouldEqual, chess.D3)
+}
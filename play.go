@@ -0,0 +1,365 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/rdk/vision/viscapture"
+)
+
+const (
+	playPollInterval = time.Second
+	stableCaptures   = 2 // the same board this many times in a row before we believe a move
+)
+
+type PlayCmd struct {
+	Start bool
+	Stop  bool
+	Color string // the robot's side, defaults to robot-color
+}
+
+func parseColor(c string) (chess.Color, error) {
+	switch strings.ToLower(c) {
+	case "white", "w":
+		return chess.White, nil
+	case "black", "b":
+		return chess.Black, nil
+	}
+	return chess.NoColor, fmt.Errorf("bad color (%s), need white or black", c)
+}
+
+// boardColors reads what's on every square from a capture: 0 - empty, 1 - white, 2 - black.
+func (s *viamChessChess) boardColors(all viscapture.VisCapture) (map[chess.Square]int, error) {
+	colors := map[chess.Square]int{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		o := s.findObject(all, sq.String())
+		if o == nil {
+			return nil, fmt.Errorf("no object for %s", sq)
+		}
+		label := o.Geometry.Label()
+		if len(label) < 4 {
+			return nil, fmt.Errorf("bad label (%s) for %s", label, sq)
+		}
+		colors[sq] = int(label[3] - '0')
+	}
+	return colors, nil
+}
+
+func positionColors(pos *chess.Position) map[chess.Square]int {
+	colors := map[chess.Square]int{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		colors[sq] = int(pos.Board().Piece(sq).Color())
+	}
+	return colors
+}
+
+func sameColors(a, b map[chess.Square]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for sq, c := range a {
+		if b[sq] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// findMoveFromColors finds the legal move that turns pos into the board we see.
+// nil if nothing has changed. Vision can't tell what a pawn promoted to, so that's a queen.
+func findMoveFromColors(pos *chess.Position, colors map[chess.Square]int) (*chess.Move, error) {
+	if sameColors(positionColors(pos), colors) {
+		return nil, nil
+	}
+
+	var found *chess.Move
+	moves := pos.ValidMoves()
+	for i := range moves {
+		m := &moves[i]
+		if !sameColors(positionColors(pos.Update(m)), colors) {
+			continue
+		}
+		if found != nil && m.Promo() != chess.Queen {
+			continue
+		}
+		found = m
+	}
+
+	if found == nil {
+		diffs := []string{}
+		for sq, c := range positionColors(pos) {
+			if colors[sq] != c {
+				diffs = append(diffs, sq.String())
+			}
+		}
+		return nil, fmt.Errorf("no legal move matches the board, differences: %v", diffs)
+	}
+	return found, nil
+}
+
+// gameOver says if the game is done, and how.
+func gameOver(game *chess.Game) (bool, string) {
+	if game.Outcome() != chess.NoOutcome {
+		return true, fmt.Sprintf("%s by %s", game.Outcome(), game.Method())
+	}
+	switch game.Position().Status() {
+	case chess.Checkmate:
+		if game.Position().Turn() == chess.White {
+			return true, "0-1 by Checkmate"
+		}
+		return true, "1-0 by Checkmate"
+	case chess.Stalemate:
+		return true, "1/2-1/2 by Stalemate"
+	}
+	return false, ""
+}
+
+// isOver is gameOver, plus losing on time.
+func (s *viamChessChess) isOver(game *chess.Game) (bool, string) {
+	if over, result := s.clock.over(); over {
+		return true, result
+	}
+	return gameOver(game)
+}
+
+// playSession is a game where the robot watches the board and answers moves on its own.
+type playSession struct {
+	robot  chess.Color
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	status    string
+	lastMove  string
+	lastHuman *moveRecord // with how good it was
+	err       error
+
+	// the board we last saw, and how many times in a row
+	last  map[chess.Square]int
+	count int
+}
+
+func (p *playSession) setStatus(status string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = status
+	p.err = err
+}
+
+func (p *playSession) toMap() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := map[string]interface{}{
+		"robot":     p.robot.Name(),
+		"status":    p.status,
+		"last_move": p.lastMove,
+	}
+	if p.lastHuman != nil {
+		m["last_human_move"] = p.lastHuman.toMap()
+	}
+	if p.err != nil {
+		m["error"] = p.err.Error()
+	}
+	return m
+}
+
+// stable counts how many times in a row we've seen this board.
+func (p *playSession) stable(colors map[chess.Square]int) bool {
+	if p.last != nil && sameColors(p.last, colors) {
+		p.count++
+	} else {
+		p.last = colors
+		p.count = 1
+	}
+	return p.count >= stableCaptures
+}
+
+func (s *viamChessChess) doPlay(cmd PlayCmd) (map[string]interface{}, error) {
+	if cmd.Stop {
+		s.stopPlay()
+		return map[string]interface{}{"play": "stopped"}, nil
+	}
+
+	if cmd.Start {
+		color := cmd.Color
+		if color == "" {
+			color = s.conf.robotColor()
+		}
+		robot, err := parseColor(color)
+		if err != nil {
+			return nil, err
+		}
+		s.startPlay(robot)
+	}
+
+	s.playLock.Lock()
+	defer s.playLock.Unlock()
+	if s.play == nil {
+		return map[string]interface{}{"play": "stopped"}, nil
+	}
+	return s.play.toMap(), nil
+}
+
+func (s *viamChessChess) startPlay(robot chess.Color) {
+	s.stopExhibition()
+	s.stopPlay()
+
+	ctx, cancel := context.WithCancel(s.cancelCtx)
+	p := &playSession{
+		robot:  robot,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: "starting",
+	}
+
+	s.playLock.Lock()
+	s.play = p
+	s.playLock.Unlock()
+
+	go func() {
+		defer close(p.done)
+		defer s.clock.stop()
+		s.playLoop(ctx, p)
+	}()
+}
+
+func (s *viamChessChess) stopPlay() {
+	s.playLock.Lock()
+	p := s.play
+	s.play = nil
+	s.playLock.Unlock()
+
+	if p == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+func (s *viamChessChess) playLoop(ctx context.Context, p *playSession) {
+	s.logger.Infof("playing %s", p.robot.Name())
+
+	s.doCommandLock.Lock()
+	err := s.goToStart(ctx) // so the camera can see
+	s.doCommandLock.Unlock()
+	if err != nil {
+		p.setStatus("can't go home", err)
+		return
+	}
+
+	ticker := time.NewTicker(playPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := s.playStep(ctx, p)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.logger.Warnf("play: %v", err)
+			p.setStatus("waiting", err)
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// playStep moves if it's our turn, otherwise looks for the human's move. Returns true when the game is over.
+func (s *viamChessChess) playStep(ctx context.Context, p *playSession) (bool, error) {
+	s.doCommandLock.Lock()
+	defer s.doCommandLock.Unlock()
+
+	theState, err := s.getGame(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if over, result := s.isOver(theState.game); over {
+		s.logger.Infof("game over: %s", result)
+		p.setStatus("game over: "+result, nil)
+		return true, nil
+	}
+	s.clock.start(theState.game.Position().Turn())
+
+	if theState.game.Position().Turn() == p.robot {
+		p.setStatus("thinking", nil)
+		m, _, err := s.makeAMove(ctx, s.player, false)
+		if err != nil {
+			return false, err
+		}
+		p.mu.Lock()
+		p.lastMove = m.String()
+		p.mu.Unlock()
+		p.last = nil
+
+		err = s.goToStart(ctx) // out of the camera's way
+		if err != nil {
+			return false, err
+		}
+
+		theState, err = s.getGame(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		over, result := s.isOver(theState.game)
+		if over {
+			p.setStatus("game over: "+result, nil)
+		}
+		return over, nil
+	}
+
+	p.setStatus("waiting for "+p.robot.Other().Name(), nil)
+
+	all, err := s.capture(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	colors, err := s.boardColors(all)
+	if err != nil {
+		return false, err
+	}
+
+	if !p.stable(colors) {
+		return false, nil
+	}
+
+	m, err := findMoveFromColors(theState.game.Position(), colors)
+	if err != nil || m == nil {
+		return false, err
+	}
+
+	rec, err := s.humanMoved(ctx, theState, m)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.saveGame(ctx, theState)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	p.lastMove = m.String()
+	p.lastHuman = rec
+	p.mu.Unlock()
+
+	over, result := s.isOver(theState.game)
+	if over {
+		p.setStatus("game over: "+result, nil)
+	}
+	return over, nil
+}
@@ -0,0 +1,90 @@
+package viamchess
+
+import (
+	"testing"
+
+	"github.com/corentings/chess/v2"
+
+	"go.viam.com/test"
+)
+
+// colorsAfter is what vision would see after the move.
+func colorsAfter(t *testing.T, pos *chess.Position, move string) map[chess.Square]int {
+	t.Helper()
+	m, err := chess.UCINotation{}.Decode(pos, move)
+	test.That(t, err, test.ShouldBeNil)
+	for _, v := range pos.ValidMoves() {
+		if v.S1() == m.S1() && v.S2() == m.S2() && v.Promo() == m.Promo() {
+			return positionColors(pos.Update(&v))
+		}
+	}
+	t.Fatalf("%s isn't legal", move)
+	return nil
+}
+
+func TestFindMoveFromColors(t *testing.T) {
+	for _, tc := range []struct {
+		name, fen, move string
+	}{
+		{"pawn", chess.StartingPosition().String(), "e2e4"},
+		{"capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5"},
+		{"castle", italianFEN, "e1g1"},
+		{"queen side", "r3kbnr/pppqpppp/2n5/3p1b2/3P1B2/2N5/PPPQPPPP/R3KBNR b KQkq - 6 5", "e8c8"},
+		{"en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6"},
+		{"promotion", "8/4P1k1/8/8/8/8/6K1/8 w - - 0 1", "e7e8q"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pos := positionFromFEN(t, tc.fen)
+			m, err := findMoveFromColors(pos, colorsAfter(t, pos, tc.move))
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, m.String(), test.ShouldEqual, tc.move)
+		})
+	}
+
+	pos := chess.StartingPosition()
+
+	m, err := findMoveFromColors(pos, positionColors(pos))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m, test.ShouldBeNil)
+
+	// a pawn jumping three squares
+	colors := positionColors(pos)
+	colors[chess.E2] = 0
+	colors[chess.E5] = 1
+	_, err = findMoveFromColors(pos, colors)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestGameOver(t *testing.T) {
+	over, _ := gameOver(chess.NewGame())
+	test.That(t, over, test.ShouldBeFalse)
+
+	opt, err := chess.FEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	test.That(t, err, test.ShouldBeNil)
+	over, result := gameOver(chess.NewGame(opt))
+	test.That(t, over, test.ShouldBeTrue)
+	test.That(t, result, test.ShouldEqual, "0-1 by Checkmate")
+
+	opt, err = chess.FEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	test.That(t, err, test.ShouldBeNil)
+	over, result = gameOver(chess.NewGame(opt))
+	test.That(t, over, test.ShouldBeTrue)
+	test.That(t, result, test.ShouldContainSubstring, "Stalemate")
+}
+
+func TestPlaySessionStable(t *testing.T) {
+	p := &playSession{}
+	a := positionColors(chess.StartingPosition())
+	b := colorsAfter(t, chess.StartingPosition(), "e2e4")
+
+	test.That(t, p.stable(a), test.ShouldBeFalse)
+	test.That(t, p.stable(a), test.ShouldBeTrue)
+	test.That(t, p.stable(b), test.ShouldBeFalse) // hand still over the board maybe
+	test.That(t, p.stable(b), test.ShouldBeTrue)
+
+	c, err := parseColor("White")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, c, test.ShouldEqual, chess.White)
+	_, err = parseColor("green")
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,189 @@
+package viamchess
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/golang/geo/r3"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/services/motion"
+	"go.viam.com/utils/trace"
+)
+
+// atStartMM is how close to the start pose the gripper has to be to not bother going there again.
+const atStartMM = 1.0
+
+// waypoint is one stop on a path, straight ones keep to a line per the motion config.
+type waypoint struct {
+	p        r3.Vector
+	straight bool
+	speed    float64 // joint degs/sec getting there, 0 for the motion config's
+}
+
+// jointCache remembers the joints the arm ended up in for each place at the safe height it's been planned to,
+// so going there again needs no planning. The arm goes between them in joint space without checking
+// the board, which is why nothing lower is kept.
+type jointCache struct {
+	mu     sync.Mutex
+	joints map[string][]referenceframe.Input
+}
+
+func jointKey(p r3.Vector) string {
+	return fmt.Sprintf("%.0f,%.0f,%.0f", p.X, p.Y, p.Z)
+}
+
+func (jc *jointCache) get(p r3.Vector) ([]referenceframe.Input, bool) {
+	if jc == nil {
+		return nil, false
+	}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	j, ok := jc.joints[jointKey(p)]
+	return j, ok
+}
+
+func (jc *jointCache) put(p r3.Vector, j []referenceframe.Input) {
+	if jc == nil {
+		return
+	}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	if jc.joints == nil {
+		jc.joints = map[string][]referenceframe.Input{}
+	}
+	jc.joints[jointKey(p)] = j
+}
+
+func (jc *jointCache) clear() {
+	if jc == nil {
+		return
+	}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	jc.joints = nil
+}
+
+func (jc *jointCache) size() int {
+	if jc == nil {
+		return 0
+	}
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	return len(jc.joints)
+}
+
+// cachedRun is how many waypoints from the start of path are free moves with joints cached, at the
+// same speed, and their joints.
+func (jc *jointCache) cachedRun(path []waypoint) [][]referenceframe.Input {
+	run := [][]referenceframe.Input{}
+	for _, w := range path {
+		if w.straight || w.speed != path[0].speed {
+			break
+		}
+		j, ok := jc.get(w.p)
+		if !ok {
+			break
+		}
+		run = append(run, j)
+	}
+	return run
+}
+
+// plan asks the motion service how the arm gets somewhere, without moving it, so a plan that can't be
+// found is told apart from a move that went wrong. Returns the arm's joints for each step.
+func (s *viamChessChess) plan(ctx context.Context, req motion.MoveReq) ([][]referenceframe.Input, error) {
+	pbReq, err := req.ToProto(s.motion.Name().ShortName())
+	if err != nil {
+		return nil, err
+	}
+	b, err := protojson.Marshal(pbReq)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.motion.DoCommand(ctx, map[string]interface{}{"plan": string(b)})
+	if err != nil {
+		return nil, err
+	}
+	if wp, ok := res["plan_partialwp"]; ok {
+		// it only got part of the way, that's no plan to run
+		return nil, fmt.Errorf("motion service only planned up to waypoint %v", wp)
+	}
+
+	var traj motionplan.Trajectory
+	err = mapstructure.Decode(res["plan"], &traj)
+	if err != nil {
+		return nil, fmt.Errorf("bad plan from motion service: %w", err)
+	}
+	return traj.GetFrameInputs(s.conf.Arm)
+}
+
+// execute runs planned joints on the arm, at speed degs/sec or the motion config's if that's 0.
+func (s *viamChessChess) execute(ctx context.Context, joints [][]referenceframe.Input, speed float64) error {
+	s.atStart = false
+	return s.arm.MoveThroughJointPositions(ctx, joints, s.conf.Motion.moveOptions(speed), nil)
+}
+
+// remember keeps where the arm is for p, after a free move got planned there, if p is at the safe height.
+func (s *viamChessChess) remember(ctx context.Context, p r3.Vector) {
+	if s.joints == nil || p.Z < s.safeZ() {
+		return
+	}
+	j, err := s.arm.JointPositions(ctx, nil)
+	if err != nil {
+		s.logger.Debugf("can't get joints to cache: %v", err)
+		return
+	}
+	s.joints.put(p, j)
+}
+
+// moveAlong goes through the path. Free moves already in the joint cache are sent to the arm together,
+// without planning, everything else is planned one at a time.
+func (s *viamChessChess) moveAlong(ctx context.Context, path []waypoint, ws *referenceframe.WorldState) error {
+	ctx, span := trace.StartSpan(ctx, "moveAlong")
+	defer span.End()
+
+	for len(path) > 0 {
+		run := s.joints.cachedRun(path)
+		if len(run) == 0 {
+			err := s.moveTo(ctx, path[0].p, ws, path[0].straight, path[0].speed)
+			if err != nil {
+				return err
+			}
+			path = path[1:]
+			continue
+		}
+
+		err := s.execute(ctx, run, path[0].speed)
+		if err != nil {
+			return fmt.Errorf("can't move through %d cached positions: %w", len(run), err)
+		}
+		path = path[len(run):]
+	}
+	return nil
+}
+
+// startThetaChanged is if the gripper got turned between two start poses, more than a tenth of a degree.
+func startThetaChanged(a, b *referenceframe.PoseInFrame) bool {
+	ta := a.Pose().Orientation().OrientationVectorDegrees().Theta
+	tb := b.Pose().Orientation().OrientationVectorDegrees().Theta
+	return math.Abs(ta-tb) > .1
+}
+
+// alreadyAtStart is if the last thing the arm did was go to the start pose, with the gripper open, and it's still there.
+func (s *viamChessChess) alreadyAtStart(ctx context.Context) bool {
+	if !s.atStart || s.startPose == nil || s.rfs == nil {
+		return false
+	}
+	now, err := s.rfs.GetPose(ctx, s.conf.Gripper, "world", nil, nil)
+	if err != nil {
+		return false
+	}
+	return now.Pose().Point().Distance(s.startPose.Pose().Point()) < atStartMM
+}
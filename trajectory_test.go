@@ -0,0 +1,156 @@
+package viamchess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/components/arm"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/motion"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/test"
+)
+
+type recordingArm struct {
+	arm.Arm
+	through [][][]referenceframe.Input
+	opts    []*arm.MoveOptions
+	err     error
+}
+
+func (a *recordingArm) MoveThroughJointPositions(ctx context.Context, positions [][]referenceframe.Input, opts *arm.MoveOptions, extra map[string]any) error {
+	a.through = append(a.through, positions)
+	a.opts = append(a.opts, opts)
+	return a.err
+}
+
+func (a *recordingArm) JointPositions(ctx context.Context, extra map[string]interface{}) ([]referenceframe.Input, error) {
+	return []referenceframe.Input{7}, nil
+}
+
+func TestJointCache(t *testing.T) {
+	var none *jointCache
+	_, ok := none.get(r3.Vector{})
+	test.That(t, ok, test.ShouldBeFalse)
+	none.put(r3.Vector{}, []referenceframe.Input{1})
+	test.That(t, none.size(), test.ShouldEqual, 0)
+
+	jc := &jointCache{}
+	jc.put(r3.Vector{X: 100.2, Y: 50, Z: 200}, []referenceframe.Input{1, 2})
+	j, ok := jc.get(r3.Vector{X: 99.8, Y: 50.1, Z: 200})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, j, test.ShouldResemble, []referenceframe.Input{1, 2})
+	_, ok = jc.get(r3.Vector{X: 105, Y: 50, Z: 200})
+	test.That(t, ok, test.ShouldBeFalse)
+
+	jc.put(r3.Vector{X: 300, Y: 50, Z: 200}, []referenceframe.Input{3, 4})
+	path := []waypoint{
+		{p: r3.Vector{X: 100, Y: 50, Z: 200}, straight: false},
+		{p: r3.Vector{X: 300, Y: 50, Z: 200}, straight: false},
+		{p: r3.Vector{X: 300, Y: 50, Z: 40}, straight: true},
+	}
+	test.That(t, len(jc.cachedRun(path)), test.ShouldEqual, 2)
+	test.That(t, len(jc.cachedRun(path[2:])), test.ShouldEqual, 0) // straight is never cached
+
+	jc.clear()
+	test.That(t, jc.size(), test.ShouldEqual, 0)
+}
+
+func TestRememberSafeOnly(t *testing.T) {
+	s := &viamChessChess{logger: logging.NewTestLogger(t), conf: &ChessConfig{}, arm: &recordingArm{}, joints: &jointCache{}}
+
+	// close to the pieces it has to be planned every time
+	s.remember(context.Background(), r3.Vector{X: 100, Z: 60})
+	test.That(t, s.joints.size(), test.ShouldEqual, 0)
+
+	s.remember(context.Background(), r3.Vector{X: 100, Z: s.safeZ()})
+	j, ok := s.joints.get(r3.Vector{X: 100, Z: s.safeZ()})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, j, test.ShouldResemble, []referenceframe.Input{7})
+
+	turned := func(theta float64) *referenceframe.PoseInFrame {
+		return referenceframe.NewPoseInFrame("world", spatialmath.NewPose(r3.Vector{}, &spatialmath.OrientationVectorDegrees{OZ: -1, Theta: theta}))
+	}
+	test.That(t, startThetaChanged(turned(90), turned(90.05)), test.ShouldBeFalse)
+	test.That(t, startThetaChanged(turned(90), turned(92)), test.ShouldBeTrue)
+}
+
+func TestMoveAlongCached(t *testing.T) {
+	a := &recordingArm{}
+	s := &viamChessChess{logger: logging.NewTestLogger(t), conf: &ChessConfig{}, arm: a, joints: &jointCache{}, atStart: true}
+	s.joints.put(r3.Vector{X: 100, Z: 200}, []referenceframe.Input{1})
+	s.joints.put(r3.Vector{X: 300, Z: 200}, []referenceframe.Input{2})
+	s.joints.put(r3.Vector{X: 300, Z: 60}, []referenceframe.Input{3})
+
+	err := s.moveAlong(context.Background(), []waypoint{
+		{p: r3.Vector{X: 100, Z: 200}, straight: false},
+		{p: r3.Vector{X: 300, Z: 200}, straight: false},
+		{p: r3.Vector{X: 300, Z: 60}, straight: false},
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, a.through, test.ShouldResemble, [][][]referenceframe.Input{{{1}, {2}, {3}}})
+	test.That(t, s.atStart, test.ShouldBeFalse)
+	test.That(t, s.alreadyAtStart(context.Background()), test.ShouldBeFalse)
+}
+
+// planningMotion plans every move to the same joints, after failing the first fails plans,
+// and only part of the way for the next partial.
+type planningMotion struct {
+	motion.Service
+	fails, partial, plans int
+}
+
+func (m *planningMotion) Name() resource.Name {
+	return motion.Named("builtin")
+}
+
+func (m *planningMotion) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	m.plans++
+	if m.plans <= m.fails {
+		return nil, errors.New("no plan")
+	}
+	res := map[string]interface{}{"plan": motionplan.Trajectory{{"arm": {0}}, {"arm": {5}}}}
+	if m.plans <= m.fails+m.partial {
+		res["plan_partialwp"] = 0
+	}
+	return res, nil
+}
+
+func TestMoveToTilts(t *testing.T) {
+	a := &recordingArm{}
+	m := &planningMotion{fails: 2}
+	s := &viamChessChess{
+		logger:    logging.NewTestLogger(t),
+		conf:      &ChessConfig{Arm: "arm", Gripper: "gripper"},
+		arm:       a,
+		motion:    m,
+		startPose: referenceframe.NewPoseInFrame("world", spatialmath.NewZeroPose()),
+	}
+
+	// the first two tilts don't plan, the third does
+	err := s.moveGripper(context.Background(), r3.Vector{X: 100, Z: 200}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.plans, test.ShouldEqual, 3)
+	test.That(t, a.through, test.ShouldResemble, [][][]referenceframe.Input{{{0}, {5}}})
+
+	// a partial plan counts as no plan
+	a.through = nil
+	m.plans, m.fails, m.partial = 0, 1, 1
+	err = s.moveGripper(context.Background(), r3.Vector{X: 100, Z: 200}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.plans, test.ShouldEqual, 3)
+	test.That(t, a.through, test.ShouldResemble, [][][]referenceframe.Input{{{0}, {5}}})
+
+	// the arm failing isn't a reason to try another tilt
+	a.err = errors.New("arm stopped")
+	m.plans, m.fails, m.partial = 0, 0, 0
+	err = s.moveGripper(context.Background(), r3.Vector{X: 100, Z: 200}, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, m.plans, test.ShouldEqual, 1)
+}
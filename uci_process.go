@@ -0,0 +1,270 @@
+package viamchess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corentings/chess/v2"
+	"github.com/corentings/chess/v2/uci"
+)
+
+var errEngineExited = errors.New("engine exited")
+
+// uciProcess is an engine process and just enough UCI to talk to it. The uci package keeps its process
+// and pipes to itself, so there's no way to kill a hung engine and let go of whatever's waiting on it.
+// Here we own both: killing it closes its pipes, and the one Wait on it is ours.
+type uciProcess struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out io.ReadCloser
+
+	lines  chan string   // what it prints, closed once it's exited
+	done   chan struct{} // closed when we're done with it, nothing is read after
+	exited chan struct{}
+
+	doneOnce sync.Once
+	writeMu  sync.Mutex
+	mu       sync.Mutex // held for a command and its reply, stop doesn't take it so it can get to a search
+	position *chess.Position
+
+	resMu   sync.Mutex
+	options map[string]uci.Option
+	results uci.SearchResults
+}
+
+func startUCI(path string) (*uciProcess, error) {
+	full, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(full)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &uciProcess{
+		cmd:    cmd,
+		in:     in,
+		out:    out,
+		lines:  make(chan string, 100),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go e.read()
+	return e, nil
+}
+
+// read passes on what the engine prints until it's gone, then reaps it.
+func (e *uciProcess) read() {
+	scanner := bufio.NewScanner(e.out)
+	for scanner.Scan() {
+		select {
+		case e.lines <- scanner.Text():
+		case <-e.done:
+		}
+	}
+	_ = e.cmd.Wait()
+	close(e.lines)
+	close(e.exited)
+}
+
+func (e *uciProcess) readLine() (string, error) {
+	s, ok := <-e.lines
+	if !ok {
+		return "", errEngineExited
+	}
+	return s, nil
+}
+
+func (e *uciProcess) send(c uci.Cmd) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	_, err := fmt.Fprintln(e.in, c.String())
+	return err
+}
+
+// Run sends cmds in order, each waiting for its reply.
+func (e *uciProcess) Run(cmds ...uci.Cmd) error {
+	for _, c := range cmds {
+		if c.String() == uci.CmdStop.String() {
+			err := e.send(c) // the search it stops gets the bestmove
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := e.runOne(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *uciProcess) runOne(c uci.Cmd) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	err := e.send(c)
+	if err != nil {
+		return err
+	}
+
+	switch c := c.(type) {
+	case uci.CmdPosition:
+		e.position = c.Position
+		return nil
+	case uci.CmdGo:
+		return e.readSearch()
+	}
+
+	switch c.String() {
+	case uci.CmdUCI.String():
+		return e.readOptions()
+	case uci.CmdIsReady.String():
+		return e.readUntil("readyok")
+	}
+	return nil // nothing else gets a reply
+}
+
+func (e *uciProcess) readUntil(want string) error {
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			return err
+		}
+		if line == want {
+			return nil
+		}
+	}
+}
+
+func (e *uciProcess) readOptions() error {
+	options := map[string]uci.Option{}
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "uciok" {
+			break
+		}
+		o := uci.Option{}
+		if o.UnmarshalText([]byte(line)) == nil {
+			options[o.Name] = o
+		}
+	}
+
+	e.resMu.Lock()
+	e.options = options
+	e.resMu.Unlock()
+	return nil
+}
+
+// readSearch reads info lines until bestmove, the same way the uci package does.
+func (e *uciProcess) readSearch() error {
+	results := uci.SearchResults{MultiPVInfo: make([]uci.Info, 1)}
+	for {
+		line, err := e.readLine()
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(line, "bestmove") {
+			parts := strings.Fields(line)
+			if len(parts) < 2 {
+				return fmt.Errorf("bad bestmove from engine (%s)", line)
+			}
+			results.BestMove, err = chess.UCINotation{}.Decode(e.position, parts[1])
+			if err != nil {
+				return err
+			}
+			break
+		}
+
+		info := uci.Info{}
+		if info.UnmarshalText([]byte(line)) != nil {
+			continue
+		}
+		if info.Multipv <= 1 {
+			results.Info = info
+		} else if info.Multipv < 300 {
+			for len(results.MultiPVInfo) < info.Multipv {
+				results.MultiPVInfo = append(results.MultiPVInfo, uci.Info{})
+			}
+			results.MultiPVInfo[info.Multipv-1] = info
+		}
+	}
+	results.MultiPVInfo[0] = results.Info
+
+	e.resMu.Lock()
+	e.results = results
+	e.resMu.Unlock()
+	return nil
+}
+
+func (e *uciProcess) Options() map[string]uci.Option {
+	e.resMu.Lock()
+	defer e.resMu.Unlock()
+	return e.options
+}
+
+func (e *uciProcess) SearchResults() uci.SearchResults {
+	e.resMu.Lock()
+	defer e.resMu.Unlock()
+	return e.results
+}
+
+func (e *uciProcess) pid() int {
+	return e.cmd.Process.Pid
+}
+
+func (e *uciProcess) finish() {
+	e.doneOnce.Do(func() {
+		close(e.done)
+	})
+}
+
+// close asks the engine to quit, killing it if it hasn't after timeout.
+func (e *uciProcess) close(timeout time.Duration) error {
+	e.finish()
+	go func() {
+		_ = e.send(uci.CmdQuit) // a hung engine can block this too
+		_ = e.in.Close()
+	}()
+
+	select {
+	case <-e.exited:
+		return nil
+	case <-time.After(timeout):
+		e.kill()
+		return errEngineHung
+	}
+}
+
+// kill takes the process down hard and waits for it. Closing stdout too means something else
+// holding it open can't keep us reading, and anything waiting on a reply gets errEngineExited.
+func (e *uciProcess) kill() {
+	e.finish()
+	_ = e.cmd.Process.Kill()
+	_ = e.out.Close()
+	_ = e.in.Close()
+	<-e.exited
+}